@@ -3,9 +3,11 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"os"
 	"port-jump/internal/options"
 	"port-jump/internal/secrets"
 	"strconv"
+	"strings"
 
 	"github.com/charmbracelet/huh"
 	"github.com/rs/zerolog/log"
@@ -16,146 +18,338 @@ import (
 var addCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add a new jump",
-	Run: func(cmd *cobra.Command, args []string) {
-		var (
-			portString     string
-			intervalString string
-			secret         string
-			confirm        bool
-
-			// converted values
-			port     int
-			interval int64
-		)
-
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewInput().
-					Title("Destination port").
-					Description("The local destination port where jumps should redirect to.").
-					Placeholder("A port number.").
-					Value(&portString).
-					Validate(func(s string) error {
-						p, err := strconv.Atoi(s)
-						if err != nil {
-							return errors.New("not a valid number")
-						}
-
-						if p < 1 || p > 65535 {
-							return errors.New("please select a port between 1 and 65535")
-						}
-
-						if checkIfJumpExists(p) {
-							return errors.New("a jump for this port is already configured")
-						}
-
-						return nil
-					}),
-				huh.NewInput().
-					Title("Interval").
-					Description("The rate, per second, a port will change.").
-					Placeholder("Number of seconds. Leave blank for default of 30.").
-					Value(&intervalString).
-					Validate(func(s string) error {
-						if s == "" {
-							// well default to 30 seconds
-							return nil
-						}
-						p, err := strconv.Atoi(s)
-						if err != nil {
-							return errors.New("not a valid number")
-						}
-
-						if p > 300 {
-							return errors.New("the number of seconds chosen is more than 5 minutes")
-						}
-
-						return nil
-					}),
-				huh.NewInput().
-					Title("Shared Secret").
-					Description("A shared secret to use with HOTP.").
-					Placeholder("16 character string. Leave blank to generated one.").
-					// CharLimit(16).
-					Value(&secret).
-					Validate(func(s string) error {
-						if s == "" {
-							// well default to a generated secret
-							return nil
-						}
-
-						if len(s) != 16 {
-							return fmt.Errorf("enter a string of 16 characters. you entered %d characters", len(s))
-						}
-
-						return nil
-					}),
-				huh.NewConfirm().
-					Title("Are you sure you want to add this jump?").
-					Affirmative("Yes!").
-					Negative("No.").
-					Value(&confirm),
-			),
-		)
-
-		err := form.Run()
-		if err == huh.ErrUserAborted {
-			return
-		}
+	Long: `Add a new jump.
 
+Without flags, a form is shown to enter the new jump's details. To add a
+jump non-interactively, specify at least the destination port with --dst.`,
+	Example: `  port-jump config add
+  port-jump config add --name ssh --dst 22 --interval 30 --secret-file ./ssh.secret --yes
+  port-jump config add --dst 443 --tag web --yes --output json`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := outputFormat(cmd)
 		if err != nil {
-			log.Error().Err(err).Msg("failed to read form input")
-			return
+			return reportError(cmd, err)
 		}
 
-		if !confirm {
-			fmt.Println("Not adding a new jump.")
-			return
-		}
+		var in jumpInput
 
-		// check for blank values to set defaults
-		if intervalString == "" {
-			intervalString = "30"
-		}
+		if cmd.Flags().Changed("dst") {
+			if err := in.fromFlags(cmd); err != nil {
+				return reportError(cmd, err)
+			}
+
+			if err := in.validate(); err != nil {
+				return reportError(cmd, err)
+			}
+
+			if err := confirmChange(cmd, "Are you sure you want to add this jump?"); err != nil {
+				if err == errNotConfirmed || err == huh.ErrUserAborted {
+					return notChanged(output, "Not adding a new jump.")
+				}
+				return reportError(cmd, err)
+			}
+		} else {
+			if !interactive() {
+				return reportError(cmd, errors.New("--dst is required when not running in a terminal"))
+			}
+
+			var confirm bool
+			form := huh.NewForm(
+				huh.NewGroup(append(in.fields(validateNewName),
+					huh.NewConfirm().
+						Title("Are you sure you want to add this jump?").
+						Affirmative("Yes!").
+						Negative("No.").
+						Value(&confirm),
+				)...),
+			)
+
+			err := form.Run()
+			if err == huh.ErrUserAborted {
+				return notChanged(output, "Not adding a new jump.")
+			}
 
-		if secret == "" {
-			secret, err = secrets.GenerateTOTPSecret(16)
 			if err != nil {
-				log.Error().Err(err).Msg("failed to generate a secret")
-				return
+				log.Error().Err(err).Msg("failed to read form input")
+				return err
+			}
+
+			if !confirm {
+				return notChanged(output, "Not adding a new jump.")
 			}
 		}
 
-		port, _ = strconv.Atoi(portString)
-		intervalInt, _ := strconv.Atoi(intervalString)
-		interval = int64(intervalInt)
+		var jump *options.PortJump
+		err = opts.Update(func(o *options.Options) error {
+			jump, err = in.jump(o)
+			if err != nil {
+				return fmt.Errorf("failed to prepare new jump: %v", err)
+			}
+
+			if checkIfJumpExists(o, jump.Name) {
+				return fmt.Errorf("a jump named %s is already configured", jump.Name)
+			}
 
-		jump, err := options.NewPortJump(port, secret, interval, true)
+			o.Jumps = append(o.Jumps, jump)
+			return nil
+		})
 		if err != nil {
-			log.Error().Err(err).Msg("failed to prepare new jump")
-			return
+			return reportError(cmd, fmt.Errorf("failed to save new jump: %v", err))
 		}
 
-		opts.Jumps = append(opts.Jumps, jump)
-		if err := opts.Save(); err != nil {
-			log.Error().Err(err).Msg("failed to save new jump")
-			return
+		if output == outputJSON {
+			return writeJSON(newJumpResult(jump, true))
 		}
 
-		fmt.Printf("New jump for port %d added!\n", port)
+		fmt.Printf("New jump %s for port %d added!\n", jump.Name, jump.DstPort)
+
+		return nil
 	},
 }
 
-func checkIfJumpExists(port int) bool {
-	for _, jump := range opts.Jumps {
-		if jump.DstPort == port {
-			return true
+// jumpInput holds the details of a jump as entered in a form or with flags
+type jumpInput struct {
+	name        string
+	description string
+	tags        string
+	iface       string
+	port        string
+	interval    string
+	secret      string
+	disabled    bool
+}
+
+// fields returns the form fields used to enter a jump's details
+func (in *jumpInput) fields(validateName func(string) error) []huh.Field {
+	return []huh.Field{
+		huh.NewInput().
+			Title("Name").
+			Description("A unique name to identify the jump with.").
+			Placeholder("Letters, digits, '.', '_' and '-'. Leave blank to name it after the port.").
+			Value(&in.name).
+			Validate(validateName),
+		huh.NewInput().
+			Title("Description").
+			Description("An optional description of the jump.").
+			Value(&in.description),
+		huh.NewInput().
+			Title("Tags").
+			Description("Optional tags to select the jump with.").
+			Placeholder("Comma separated tags.").
+			Value(&in.tags),
+		huh.NewInput().
+			Title("Destination port").
+			Description("The local destination port where jumps should redirect to.").
+			Placeholder("A port number.").
+			Value(&in.port).
+			Validate(validatePort),
+		huh.NewInput().
+			Title("Interval").
+			Description("The rate, per second, a port will change.").
+			Placeholder("Number of seconds. Leave blank for default of 30.").
+			Value(&in.interval).
+			Validate(validateInterval),
+		huh.NewInput().
+			Title("Shared Secret").
+			Description("A shared secret to use with HOTP.").
+			Placeholder("16 character string. Leave blank to generated one.").
+			Value(&in.secret).
+			Validate(validateSecret),
+	}
+}
+
+// fromFlags reads a jump's details from command flags
+func (in *jumpInput) fromFlags(cmd *cobra.Command) error {
+	in.name, _ = cmd.Flags().GetString("name")
+	in.description, _ = cmd.Flags().GetString("description")
+	in.iface, _ = cmd.Flags().GetString("interface")
+	in.secret, _ = cmd.Flags().GetString("secret")
+	in.disabled, _ = cmd.Flags().GetBool("disabled")
+
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	in.tags = strings.Join(tags, ",")
+
+	if cmd.Flags().Changed("dst") {
+		dst, _ := cmd.Flags().GetInt("dst")
+		in.port = strconv.Itoa(dst)
+	}
+
+	if cmd.Flags().Changed("interval") {
+		interval, _ := cmd.Flags().GetInt64("interval")
+		in.interval = strconv.FormatInt(interval, 10)
+	}
+
+	secretFile, _ := cmd.Flags().GetString("secret-file")
+	if secretFile != "" {
+		if in.secret != "" {
+			return errors.New("use only one of --secret and --secret-file")
+		}
+
+		data, err := os.ReadFile(secretFile)
+		if err != nil {
+			return fmt.Errorf("failed to read secret file: %v", err)
+		}
+
+		in.secret = strings.TrimSpace(string(data))
+	}
+
+	return nil
+}
+
+// validate validates the entered details, just like the form does
+func (in *jumpInput) validate() error {
+	if err := validateNewName(in.name); err != nil {
+		return fmt.Errorf("invalid name: %v", err)
+	}
+
+	if err := validatePort(in.port); err != nil {
+		return fmt.Errorf("invalid destination port: %v", err)
+	}
+
+	if err := validateInterval(in.interval); err != nil {
+		return fmt.Errorf("invalid interval: %v", err)
+	}
+
+	if err := validateSecret(in.secret); err != nil {
+		return fmt.Errorf("invalid secret: %v", err)
+	}
+
+	return nil
+}
+
+// jump returns a new jump from the entered details, filling in defaults for
+// blank values. A blank name defaults to a name not used in o yet.
+func (in *jumpInput) jump(o *options.Options) (*options.PortJump, error) {
+	var err error
+
+	interval := in.interval
+	if interval == "" {
+		interval = "30"
+	}
+
+	secret := in.secret
+	if secret == "" {
+		secret, err = secrets.GenerateTOTPSecret(16)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate a secret: %v", err)
 		}
 	}
 
-	return false
+	port, _ := strconv.Atoi(in.port)
+	intervalInt, _ := strconv.Atoi(interval)
+
+	name := in.name
+	if name == "" {
+		name = o.DefaultName(port)
+	}
+
+	jump, err := options.NewPortJump(name, port, secret, int64(intervalInt), !in.disabled)
+	if err != nil {
+		return nil, err
+	}
+
+	jump.Description = in.description
+	jump.Tags = splitTags(in.tags)
+	jump.Interface = in.iface
+
+	return jump, nil
+}
+
+// validateNewName validates the name of a jump that does not exist yet
+func validateNewName(s string) error {
+	if s == "" {
+		// well default to a name based on the port
+		return nil
+	}
+
+	if err := options.ValidateName(s); err != nil {
+		return err
+	}
+
+	if checkIfJumpExists(opts, s) {
+		return errors.New("a jump with this name is already configured")
+	}
+
+	return nil
+}
+
+// validatePort validates a destination port
+func validatePort(s string) error {
+	p, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.New("not a valid number")
+	}
+
+	if p < 1 || p > 65535 {
+		return errors.New("please select a port between 1 and 65535")
+	}
+
+	return nil
+}
+
+// validateInterval validates an interval, where blank means the default
+func validateInterval(s string) error {
+	if s == "" {
+		// well default to 30 seconds
+		return nil
+	}
+	p, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.New("not a valid number")
+	}
+
+	if p < 1 {
+		return errors.New("the number of seconds has to be more than 0")
+	}
+
+	if p > 300 {
+		return errors.New("the number of seconds chosen is more than 5 minutes")
+	}
+
+	return nil
+}
+
+// validateSecret validates a shared secret, where blank means a generated one
+func validateSecret(s string) error {
+	if s == "" {
+		// well default to a generated secret
+		return nil
+	}
+
+	return options.ValidateSecret(s)
+}
+
+func checkIfJumpExists(o *options.Options, name string) bool {
+	return o.Jump(name) != nil
+}
+
+// splitTags splits a comma separated list of tags, dropping empty values
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
 }
 
 func init() {
 	configCmd.AddCommand(addCmd)
+
+	addCmd.Flags().String("name", "", "Name of the new jump. Defaults to a name based on the port")
+	addCmd.Flags().String("description", "", "Description of the new jump")
+	addCmd.Flags().StringSlice("tag", nil, "Tag to add to the new jump. Can be repeated")
+	addCmd.Flags().String("interface", "", "Only redirect traffic arriving on this network interface")
+	addCmd.Flags().Int("dst", 0, "Destination port where jumps should redirect to")
+	addCmd.Flags().Int64("interval", options.DefaultInterval, "The rate, in seconds, a port will change")
+	addCmd.Flags().String("secret", "", "Shared secret to use. Defaults to a generated secret")
+	addCmd.Flags().String("secret-file", "", "Read the shared secret to use from a file")
+	addCmd.Flags().Bool("disabled", false, "Add the jump in a disabled state")
+	addCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+	addOutputFlag(addCmd)
 }
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"port-jump/internal/options"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Reconcile jumps with a desired-state file",
+	Long: `Reconcile jumps with a desired-state file.
+
+The desired-state file uses the configuration file format. Jumps are matched
+by name: missing jumps are added, changed jumps are updated and jumps that are
+not in the file are removed. A plan of the changes is shown before applying it.
+
+Existing jumps keep their shared secret if the desired jump has none. Use
+--preserve-secrets to always keep existing shared secrets. New jumps without a
+shared secret get a generated one. Jumps that do not set enabled keep their
+current state, and new ones are enabled.`,
+	Example: `  port-jump config apply -f jumps.yml --dry-run
+  port-jump config apply -f jumps.yml --preserve-secrets --yes
+  cat jumps.yml | port-jump config apply -f - --yes --output json`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := outputFormat(cmd)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		file, _ := cmd.Flags().GetString("file")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		preserve, _ := cmd.Flags().GetBool("preserve-secrets")
+
+		if file == "" {
+			return reportError(cmd, errors.New("a desired-state file needs to be specified with --file"))
+		}
+
+		desired, err := options.ReadDesiredJumps(file)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		_, plan, err := options.Reconcile(opts.Jumps, desired, preserve)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		if output == outputText {
+			printPlan(plan)
+		}
+
+		if dryRun || len(plan) == 0 {
+			if output == outputJSON {
+				return writeJSON(applyResult{DryRun: dryRun, Plan: planOrEmpty(plan)})
+			}
+
+			return nil
+		}
+
+		if err := confirmChange(cmd, "Are you sure you want to apply this plan?"); err != nil {
+			if err == errNotConfirmed || err == huh.ErrUserAborted {
+				return notChanged(output, "Not applying the plan.")
+			}
+			return reportError(cmd, err)
+		}
+
+		// reconcile again with the latest configuration, which is what gets saved
+		err = opts.Update(func(o *options.Options) error {
+			jumps, latest, err := options.Reconcile(o.Jumps, desired, preserve)
+			if err != nil {
+				return err
+			}
+
+			o.Jumps = jumps
+			plan = latest
+
+			// refuse to save jumps that would fail to derive ports
+			var invalid []string
+			for _, problem := range o.Validate() {
+				if problem.Severity == options.SeverityError {
+					invalid = append(invalid, problem.String())
+				}
+			}
+
+			if len(invalid) > 0 {
+				return fmt.Errorf("invalid jumps in the desired state: %s", strings.Join(invalid, "; "))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return reportError(cmd, fmt.Errorf("failed to apply plan: %v", err))
+		}
+
+		if output == outputJSON {
+			return writeJSON(applyResult{Applied: true, Plan: planOrEmpty(plan)})
+		}
+
+		fmt.Println("Plan applied.")
+
+		return nil
+	},
+}
+
+// applyResult is the JSON output of the apply command
+type applyResult struct {
+	DryRun  bool               `json:"dry_run"`
+	Applied bool               `json:"applied"`
+	Plan    []options.PlanItem `json:"plan"`
+}
+
+// planOrEmpty returns plan, or an empty plan if it is nil
+func planOrEmpty(plan []options.PlanItem) []options.PlanItem {
+	if plan == nil {
+		return []options.PlanItem{}
+	}
+
+	return plan
+}
+
+// printPlan prints the changes a plan makes
+func printPlan(plan []options.PlanItem) {
+	var (
+		createStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))  // Green
+		updateStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214")) // Orange
+		deleteStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196")) // Red
+	)
+
+	if len(plan) == 0 {
+		fmt.Println("No changes. Jumps match the desired state.")
+		return
+	}
+
+	var creates, updates, deletes int
+	for _, item := range plan {
+		switch item.Action {
+		case options.ActionCreate:
+			creates++
+			fmt.Println(createStyle.Render("+ create " + item.Name))
+		case options.ActionUpdate:
+			updates++
+			fmt.Println(updateStyle.Render("~ update " + item.Name))
+			for _, change := range item.Changes {
+				fmt.Printf("    %s\n", change)
+			}
+		case options.ActionDelete:
+			deletes++
+			fmt.Println(deleteStyle.Render("- delete " + item.Name))
+		}
+	}
+
+	fmt.Printf("\nPlan: %d to add, %d to change, %d to remove.\n", creates, updates, deletes)
+}
+
+func init() {
+	configCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringP("file", "f", "", "Desired-state file to apply, or - to read from stdin")
+	applyCmd.Flags().Bool("dry-run", false, "Only show the plan, do not apply it")
+	applyCmd.Flags().Bool("preserve-secrets", false, "Always keep the shared secrets of existing jumps")
+	applyCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+	addOutputFlag(applyCmd)
+}
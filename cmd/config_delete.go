@@ -1,95 +1,100 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"port-jump/internal/options"
 
 	"github.com/charmbracelet/huh"
-	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
 // deleteCmd represents the delete command
 var deleteCmd = &cobra.Command{
-	Use:   "delete",
+	Use:   "delete [name]",
 	Short: "Delete a jump",
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(opts.Jumps) == 0 {
-			fmt.Println("There are no configured jumps to toggle.")
-			return
+	Long: `Delete a jump.
+
+Without a name, --tag or --port, a form is shown to select the jump to delete.`,
+	Example: `  port-jump config delete
+  port-jump config delete ssh --yes
+  port-jump config delete --tag legacy --yes --output json`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := outputFormat(cmd)
+		if err != nil {
+			return reportError(cmd, err)
 		}
 
-		selectOptions := make([]huh.Option[int], 0, len(opts.Jumps))
-		for _, jump := range opts.Jumps {
-			selectOptions = append(selectOptions, huh.NewOption(
-				fmt.Sprintf("Port %d, Interval %d (enabled: %s)", jump.DstPort, jump.Interval, styledBool(jump.Enabled)),
-				jump.DstPort,
-			))
+		if len(opts.Jumps) == 0 {
+			return reportError(cmd, errors.New("there are no configured jumps to delete"))
 		}
 
-		var selectedJumpPort int
-		var confirm bool
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewSelect[int]().
-					Title("Select a jump").
-					Options(selectOptions...).
-					Value(&selectedJumpPort),
-				huh.NewConfirm().
-					Title("Are you sure you want to delete this jump?").
-					Affirmative("Yes!").
-					Negative("No.").
-					Value(&confirm),
-			),
-		)
-
-		err := form.Run()
+		selector, err := selectJumps(cmd, args)
 		if err == huh.ErrUserAborted {
-			return
+			return notChanged(output, "Not deleting a jump.")
+		}
+		if err != nil {
+			return reportError(cmd, err)
 		}
 
+		if err := confirmChange(cmd, "Are you sure you want to delete this jump?"); err != nil {
+			if err == errNotConfirmed || err == huh.ErrUserAborted {
+				return notChanged(output, "Not deleting a jump.")
+			}
+			return reportError(cmd, err)
+		}
+
+		deleted, err := deleteJumps(selector)
 		if err != nil {
-			log.Error().Err(err).Msg("failed to read form input")
-			return
+			return reportError(cmd, fmt.Errorf("failed to delete jump: %v", err))
 		}
 
-		if !confirm {
-			fmt.Println("Not deleting a jump.")
-			return
+		if output == outputJSON {
+			return writeJSON(newJumpResults(deleted))
 		}
 
-		if err := deleteJump((selectedJumpPort)); err != nil {
-			log.Error().Err(err).Msg("failed to delete jump")
-			return
+		for _, jump := range deleted {
+			fmt.Printf("Jump %s deleted.\n", jump.Name)
 		}
 
-		fmt.Printf("Jump %d deleted.\n", selectedJumpPort)
+		return nil
 	},
 }
 
-func deleteJump(port int) error {
-	index := -1
+// deleteJumps deletes every jump matching selector, returning the deleted jumps
+func deleteJumps(selector options.Selector) ([]*options.PortJump, error) {
+	var deleted []*options.PortJump
+
+	err := opts.Update(func(o *options.Options) error {
+		kept := make([]*options.PortJump, 0, len(o.Jumps))
+		for _, jump := range o.Jumps {
+			if selector.Matches(jump) {
+				deleted = append(deleted, jump)
+				continue
+			}
 
-	// Find the index of the jump to delete
-	for i, jump := range opts.Jumps {
-		if jump.DstPort == port {
-			index = i
-			break
+			kept = append(kept, jump)
 		}
-	}
 
-	if index == -1 {
-		return fmt.Errorf("jump with port %d not found", port)
-	}
+		if len(deleted) == 0 {
+			return fmt.Errorf("no jumps match %s", selector)
+		}
 
-	// Remove the jump from the slice
-	opts.Jumps = append(opts.Jumps[:index], opts.Jumps[index+1:]...)
-	if err := opts.Save(); err != nil {
-		return err
-	}
+		o.Jumps = kept
 
-	return nil
+		return nil
+	})
+
+	return deleted, err
 }
 
 func init() {
 	configCmd.AddCommand(deleteCmd)
+
+	addSelectorFlags(deleteCmd)
+	deleteCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+	addOutputFlag(deleteCmd)
 }
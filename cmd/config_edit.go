@@ -0,0 +1,414 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"port-jump/internal/bundle"
+	"port-jump/internal/options"
+	"port-jump/internal/secrets"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit [name]",
+	Short: "Edit an existing jump",
+	Long: `Edit an existing jump.
+
+Without flags, a form prefilled with the jump's current details is shown.
+Changes are shown before they are saved. If the shared secret changed,
+the configuration clients need is printed.
+
+Unlike the other config commands, edit only selects a jump by its name, as
+--tag replaces the jump's tags. Without a name, the jump is chosen from a
+list when running in a terminal.`,
+	Example: `  port-jump config edit ssh
+  port-jump config edit ssh --interval 60 --yes
+  port-jump config edit ssh --new-secret --yes --output json`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := outputFormat(cmd)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		if len(opts.Jumps) == 0 {
+			return reportError(cmd, errors.New("there are no configured jumps to edit"))
+		}
+
+		var selector options.Selector
+		if len(args) > 0 {
+			selector.Name = args[0]
+		} else if !interactive() {
+			return reportError(cmd, errors.New("a jump name needs to be specified when not running in a terminal"))
+		}
+
+		selector, err = chooseJumps(selector)
+		if err == huh.ErrUserAborted {
+			return notChanged(output, "Not editing the jump.")
+		}
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		current, err := opts.SelectOne(selector)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		in := jumpInputFrom(current)
+		enabled := current.Enabled
+
+		if editFlagsChanged(cmd) {
+			if err := in.fromEditFlags(cmd, &enabled); err != nil {
+				return reportError(cmd, err)
+			}
+
+			if err := in.validateEdit(current.Name); err != nil {
+				return reportError(cmd, err)
+			}
+		} else {
+			if !interactive() {
+				return reportError(cmd, errors.New("specify the fields to change with flags when not running in a terminal"))
+			}
+
+			fields := append(in.fields(validateRename(current.Name)),
+				huh.NewConfirm().
+					Title("Enabled").
+					Affirmative("Yes").
+					Negative("No").
+					Value(&enabled),
+			)
+
+			err := huh.NewForm(huh.NewGroup(fields...)).Run()
+			if err == huh.ErrUserAborted {
+				return notChanged(output, "Not editing the jump.")
+			}
+
+			if err != nil {
+				return reportError(cmd, fmt.Errorf("failed to read form input: %v", err))
+			}
+		}
+
+		in.disabled = !enabled
+
+		edited, err := in.apply(current)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		changes := options.DiffJump(current, edited)
+		if len(changes) == 0 {
+			if output == outputJSON {
+				return writeJSON(editResult{Name: current.Name, Changes: []options.Change{}})
+			}
+
+			fmt.Println("Nothing to change.")
+			return nil
+		}
+
+		if output == outputText {
+			printChanges(current.Name, changes)
+		}
+
+		if err := confirmChange(cmd, "Are you sure you want to save these changes?"); err != nil {
+			if err == errNotConfirmed || err == huh.ErrUserAborted {
+				return notChanged(output, "Not editing the jump.")
+			}
+			return reportError(cmd, err)
+		}
+
+		err = opts.Update(func(o *options.Options) error {
+			target := o.Jump(current.Name)
+			if target == nil {
+				return fmt.Errorf("jump %s no longer exists", current.Name)
+			}
+
+			if edited.Name != current.Name && o.Jump(edited.Name) != nil {
+				return fmt.Errorf("a jump named %s is already configured", edited.Name)
+			}
+
+			*target = *edited
+
+			return nil
+		})
+		if err != nil {
+			return reportError(cmd, fmt.Errorf("failed to save jump: %v", err))
+		}
+
+		secretChanged := edited.SharedSecret != current.SharedSecret
+
+		if output == outputJSON {
+			result := newJumpResult(edited, secretChanged)
+			return writeJSON(editResult{
+				Name:    current.Name,
+				Changes: changes,
+				Jump:    &result,
+			})
+		}
+
+		fmt.Printf("Jump %s updated.\n", edited.Name)
+
+		if secretChanged {
+			bundled := edited
+			if options.IsVaultRef(edited.SharedSecret) {
+				// clients need the secret the new reference resolves to
+				reloaded, err := opts.Reload()
+				if err != nil {
+					return reportError(cmd, fmt.Errorf("failed to resolve the new shared secret: %v", err))
+				}
+
+				if bundled = reloaded.Jump(edited.Name); bundled == nil {
+					return reportError(cmd, fmt.Errorf("jump %s no longer exists", edited.Name))
+				}
+			}
+
+			fmt.Println("\nThe shared secret changed. Import this bundle on clients with 'port-jump config import':")
+			if err := printClientBundle(bundled); err != nil {
+				return reportError(cmd, err)
+			}
+		}
+
+		return nil
+	},
+}
+
+// editResult is the JSON output of the edit command
+type editResult struct {
+	Name    string           `json:"name"`
+	Changes []options.Change `json:"changes"`
+	Jump    *jumpResult      `json:"jump,omitempty"`
+}
+
+// editFlags are the flags that change a jump's details
+var editFlags = []string{"rename", "description", "tag", "interface", "dst", "interval", "secret", "secret-file", "new-secret", "enabled"}
+
+// editFlagsChanged reports if any of the edit flags were set
+func editFlagsChanged(cmd *cobra.Command) bool {
+	for _, flag := range editFlags {
+		if cmd.Flags().Changed(flag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jumpInputFrom returns a jumpInput prefilled with the details of jump
+func jumpInputFrom(jump *options.PortJump) *jumpInput {
+	return &jumpInput{
+		name:        jump.Name,
+		description: jump.Description,
+		tags:        strings.Join(jump.Tags, ","),
+		iface:       jump.Interface,
+		port:        strconv.Itoa(jump.DstPort),
+		interval:    strconv.FormatInt(jump.Interval, 10),
+		secret:      jump.SharedSecret,
+		disabled:    !jump.Enabled,
+	}
+}
+
+// fromEditFlags overrides details with the edit flags that were set
+func (in *jumpInput) fromEditFlags(cmd *cobra.Command, enabled *bool) error {
+	flags := cmd.Flags()
+
+	if flags.Changed("rename") {
+		in.name, _ = flags.GetString("rename")
+	}
+
+	if flags.Changed("description") {
+		in.description, _ = flags.GetString("description")
+	}
+
+	if flags.Changed("tag") {
+		tags, _ := flags.GetStringSlice("tag")
+		in.tags = strings.Join(tags, ",")
+	}
+
+	if flags.Changed("interface") {
+		in.iface, _ = flags.GetString("interface")
+	}
+
+	if flags.Changed("dst") {
+		dst, _ := flags.GetInt("dst")
+		in.port = strconv.Itoa(dst)
+	}
+
+	if flags.Changed("interval") {
+		interval, _ := flags.GetInt64("interval")
+		in.interval = strconv.FormatInt(interval, 10)
+	}
+
+	if flags.Changed("enabled") {
+		*enabled, _ = flags.GetBool("enabled")
+	}
+
+	secretFlags := 0
+	for _, flag := range []string{"secret", "secret-file", "new-secret"} {
+		if flags.Changed(flag) {
+			secretFlags++
+		}
+	}
+
+	if secretFlags > 1 {
+		return errors.New("use only one of --secret, --secret-file and --new-secret")
+	}
+
+	switch {
+	case flags.Changed("secret"):
+		in.secret, _ = flags.GetString("secret")
+		if in.secret == "" {
+			return errors.New("--secret cannot be empty, use --new-secret to generate one")
+		}
+	case flags.Changed("secret-file"):
+		secretFile, _ := flags.GetString("secret-file")
+		data, err := os.ReadFile(secretFile)
+		if err != nil {
+			return fmt.Errorf("failed to read secret file: %v", err)
+		}
+		in.secret = strings.TrimSpace(string(data))
+	case flags.Changed("new-secret"):
+		// a blank secret is replaced with a generated one
+		in.secret = ""
+	}
+
+	return nil
+}
+
+// validateEdit validates the details of an edited jump currently called name
+func (in *jumpInput) validateEdit(name string) error {
+	if err := validateRename(name)(in.name); err != nil {
+		return fmt.Errorf("invalid name: %v", err)
+	}
+
+	if err := validatePort(in.port); err != nil {
+		return fmt.Errorf("invalid destination port: %v", err)
+	}
+
+	if err := validateInterval(in.interval); err != nil {
+		return fmt.Errorf("invalid interval: %v", err)
+	}
+
+	if err := validateSecret(in.secret); err != nil {
+		return fmt.Errorf("invalid secret: %v", err)
+	}
+
+	return nil
+}
+
+// apply returns a copy of jump with the entered details applied. A blank
+// secret is replaced with a newly generated one.
+func (in *jumpInput) apply(jump *options.PortJump) (*options.PortJump, error) {
+	edited := jump.Clone()
+
+	secret := in.secret
+	if secret == "" {
+		var err error
+		secret, err = secrets.GenerateTOTPSecret(16)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate a secret: %v", err)
+		}
+	}
+
+	interval := in.interval
+	if interval == "" {
+		interval = "30"
+	}
+
+	port, _ := strconv.Atoi(in.port)
+	intervalInt, _ := strconv.Atoi(interval)
+
+	edited.Name = in.name
+	edited.Description = in.description
+	edited.Tags = splitTags(in.tags)
+	edited.Interface = in.iface
+	edited.DstPort = port
+	edited.Interval = int64(intervalInt)
+	edited.SetSharedSecret(secret)
+	edited.Enabled = !in.disabled
+
+	return edited, nil
+}
+
+// validateRename returns a name validator for a jump currently called name
+func validateRename(name string) func(string) error {
+	return func(s string) error {
+		if s == name {
+			return nil
+		}
+
+		if s == "" {
+			return errors.New("name cant be empty")
+		}
+
+		return validateNewName(s)
+	}
+}
+
+// printChanges prints the changes about to be made to a jump
+func printChanges(name string, changes []options.Change) {
+	var (
+		fieldStyle = lipgloss.NewStyle().Bold(true)
+		oldStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196")) // Red
+		newStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))  // Green
+	)
+
+	fmt.Printf("Changes to jump %s:\n", name)
+	for _, change := range changes {
+		fmt.Printf("  %s\n", fieldStyle.Render(change.Field))
+		fmt.Printf("    %s\n", oldStyle.Render("- "+change.Old))
+		fmt.Printf("    %s\n", newStyle.Render("+ "+change.New))
+	}
+	fmt.Println()
+}
+
+// printClientBundle prints the client bundle a client needs for jump
+func printClientBundle(jump *options.PortJump) error {
+	if err := jump.ResolveErr(); err != nil {
+		return err
+	}
+
+	if options.IsVaultRef(jump.Secret()) {
+		return fmt.Errorf("the shared secret of %s is an unresolved vault reference", jump.Name)
+	}
+
+	data, err := bundle.New("", jump).Marshal("")
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(string(data))
+
+	return nil
+}
+
+// addEditFlags adds the flags of the edit command to cmd
+func addEditFlags(cmd *cobra.Command) {
+	cmd.Flags().String("rename", "", "New name for the jump")
+	cmd.Flags().String("description", "", "New description for the jump")
+	cmd.Flags().StringSlice("tag", nil, "Replace the jump's tags. Can be repeated")
+	cmd.Flags().String("interface", "", "Only redirect traffic arriving on this network interface")
+	cmd.Flags().Int("dst", 0, "New destination port")
+	// edit flags have no defaults, only flags that are set change the jump
+	cmd.Flags().Int64("interval", 0, "New rate, in seconds, a port will change")
+	cmd.Flags().String("secret", "", "New shared secret to use")
+	cmd.Flags().String("secret-file", "", "Read the new shared secret from a file")
+	cmd.Flags().Bool("new-secret", false, "Generate a new shared secret")
+	cmd.Flags().Bool("enabled", false, "Set the enabled state, i.e. --enabled=false to disable the jump")
+	cmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+	addOutputFlag(cmd)
+}
+
+func init() {
+	configCmd.AddCommand(editCmd)
+
+	addEditFlags(editCmd)
+}
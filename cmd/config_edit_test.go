@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"port-jump/internal/options"
+
+	"github.com/spf13/cobra"
+)
+
+func TestEditJump(t *testing.T) {
+	saved := opts
+	defer func() { opts = saved }()
+
+	opts = &options.Options{Jumps: []*options.PortJump{
+		{Name: "ssh", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP", Tags: []string{"prod"}},
+		{Name: "web", Enabled: true, DstPort: 443, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"},
+	}}
+
+	tests := []struct {
+		name string
+		args []string
+		// changes lists the changed fields, with the new value unless it is a secret
+		changes []string
+		wantErr string
+	}{
+		{
+			name: "no flags",
+		},
+		{
+			name:    "details",
+			args:    []string{"--rename", "bastion", "--dst", "2222", "--tag", "prod,eu", "--enabled=false"},
+			changes: []string{"name=bastion", "tags=prod,eu", "enabled=false", "dstport=2222"},
+		},
+		{
+			name:    "interval and interface",
+			args:    []string{"--interval", "60", "--interface", "eth0"},
+			changes: []string{"interface=eth0", "interval=60"},
+		},
+		{
+			name:    "new secret",
+			args:    []string{"--new-secret"},
+			changes: []string{"sharedsecret"},
+		},
+		{
+			name:    "secret reference",
+			args:    []string{"--secret", "vault:secret/port-jump/ssh#sharedsecret"},
+			changes: []string{"sharedsecret=vault:secret/port-jump/ssh#sharedsecret"},
+		},
+		{
+			name:    "rename to an existing jump",
+			args:    []string{"--rename", "web"},
+			wantErr: "invalid name: a jump with this name is already configured",
+		},
+		{
+			name:    "invalid secret",
+			args:    []string{"--secret", "not base32!!"},
+			wantErr: "invalid secret",
+		},
+		{
+			name:    "more than one secret flag",
+			args:    []string{"--secret", "JBSWY3DPEHPK3PXP", "--new-secret"},
+			wantErr: "use only one of --secret, --secret-file and --new-secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addEditFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			current := opts.Jump("ssh")
+			in := jumpInputFrom(current)
+
+			enabled := current.Enabled
+			err := in.fromEditFlags(cmd, &enabled)
+			in.disabled = !enabled
+			if err == nil {
+				err = in.validateEdit(current.Name)
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("edit error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("edit error = %v", err)
+			}
+
+			edited, err := in.apply(current)
+			if err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+
+			var got []string
+			for _, change := range options.DiffJump(current, edited) {
+				if change.Field == "sharedsecret" && change.New == "********" {
+					got = append(got, change.Field)
+					continue
+				}
+				got = append(got, change.Field+"="+change.New)
+			}
+
+			if strings.Join(got, " ") != strings.Join(tt.changes, " ") {
+				t.Errorf("changes = %q, want %q", got, tt.changes)
+			}
+
+			if current.Name != "ssh" || current.DstPort != 22 {
+				t.Errorf("apply() changed the current jump to %+v", current)
+			}
+		})
+	}
+}
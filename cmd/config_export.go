@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"port-jump/internal/bundle"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [name]",
+	Short: "Export a client bundle for a jump",
+	Long: `Export a client bundle for a jump.
+
+A client bundle contains everything a client needs to derive a single jump's
+port: the host, destination port, interval, shared secret and algorithm
+parameters. Unlike the configuration file, it does not contain any other
+jump's secrets. Bundles can be encrypted with a passphrase, and are imported
+on a client with 'port-jump config import'.`,
+	Example: `  port-jump config export ssh --host bastion.example.com
+  port-jump config export ssh --host 10.0.0.5 --encrypt --out ssh.bundle
+  port-jump config export -t web --passphrase-file ./passphrase`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selector := jumpSelector(cmd, args)
+		if selector.Empty() {
+			return reportError(cmd, errors.New("a jump name, tag or port needs to be specified"))
+		}
+
+		jump, err := opts.SelectOne(selector)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		host, _ := cmd.Flags().GetString("host")
+		out, _ := cmd.Flags().GetString("out")
+		encrypt, _ := cmd.Flags().GetBool("encrypt")
+
+		passphrase, err := readPassphrase(cmd)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		if encrypt && passphrase == "" {
+			passphrase, err = promptPassphrase(true)
+			if err != nil {
+				return reportError(cmd, err)
+			}
+		}
+
+		// bundles carry the resolved secret
+		if err := jump.ResolveErr(); err != nil {
+			return reportError(cmd, err)
+		}
+
+		data, err := bundle.New(host, jump).Marshal(passphrase)
+		if err != nil {
+			return reportError(cmd, fmt.Errorf("failed to prepare bundle: %v", err))
+		}
+
+		if out == "" || out == "-" {
+			fmt.Print(string(data))
+			return nil
+		}
+
+		if err := os.WriteFile(out, data, 0600); err != nil {
+			return reportError(cmd, fmt.Errorf("failed to write bundle: %v", err))
+		}
+
+		fmt.Fprintf(os.Stderr, "Bundle for jump %s written to %s.\n", jump.Name, out)
+
+		return nil
+	},
+}
+
+// readPassphrase returns the passphrase read from --passphrase-file, if set
+func readPassphrase(cmd *cobra.Command) (string, error) {
+	file, _ := cmd.Flags().GetString("passphrase-file")
+	if file == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase file: %v", err)
+	}
+
+	passphrase := strings.TrimRight(string(data), "\r\n")
+	if passphrase == "" {
+		return "", errors.New("the passphrase file is empty")
+	}
+
+	return passphrase, nil
+}
+
+// promptPassphrase asks for a passphrase, optionally asking to repeat it
+func promptPassphrase(repeat bool) (string, error) {
+	if !interactive() {
+		return "", errors.New("not running in a terminal, use --passphrase-file to provide a passphrase")
+	}
+
+	var passphrase, again string
+	fields := []huh.Field{
+		huh.NewInput().
+			Title("Passphrase").
+			EchoMode(huh.EchoModePassword).
+			Value(&passphrase).
+			Validate(func(s string) error {
+				if len(s) < 8 {
+					return errors.New("use a passphrase of at least 8 characters")
+				}
+				return nil
+			}),
+	}
+
+	if repeat {
+		fields = append(fields, huh.NewInput().
+			Title("Repeat passphrase").
+			EchoMode(huh.EchoModePassword).
+			Value(&again).
+			Validate(func(s string) error {
+				if s != passphrase {
+					return errors.New("the passphrases do not match")
+				}
+				return nil
+			}))
+	}
+
+	if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+		return "", err
+	}
+
+	return passphrase, nil
+}
+
+func init() {
+	configCmd.AddCommand(exportCmd)
+
+	addSelectorFlags(exportCmd)
+	exportCmd.Flags().String("host", "", "Host name or address clients use to reach the jump")
+	exportCmd.Flags().String("out", "", "Write the bundle to a file instead of stdout")
+	exportCmd.Flags().Bool("encrypt", false, "Encrypt the bundle with a passphrase that is asked for")
+	exportCmd.Flags().String("passphrase-file", "", "Encrypt the bundle with the passphrase in a file")
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"port-jump/internal/bundle"
+	"port-jump/internal/options"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import [file|uri]",
+	Short: "Import a client bundle or otpauth URI",
+	Long: `Import a client bundle exported with 'port-jump config export', or an
+otpauth:// URI as shown by 'port-jump config otpauth'.
+
+The bundle is read from file, or stdin if no file or - is given. Encrypted
+bundles need a passphrase. Bundles that name the host serving them are
+imported into a host entry for it, so jumps of different servers do not
+collide. Choose or name the host entry with --host. URIs exported from
+authenticator apps carry no destination port, set it with --dst. Existing
+jumps are never overwritten: if a jump with the same name exists, import it
+under a different name with --name.`,
+	Example: `  port-jump config import ssh.bundle
+  port-jump config import ssh.bundle --name prod-ssh --passphrase-file ./passphrase
+  port-jump config export ssh | ssh client port-jump config import
+  port-jump config import 'otpauth://totp/port-jump:ssh?secret=...' --dst 22`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := outputFormat(cmd)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		b, err := readBundle(cmd, args)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		if name, _ := cmd.Flags().GetString("name"); name != "" {
+			b.Jump.Name = name
+		}
+
+		if cmd.Flags().Changed("dst") {
+			b.Jump.DstPort, _ = cmd.Flags().GetInt("dst")
+		}
+
+		if b.Jump.DstPort == 0 {
+			return reportError(cmd, errors.New("the bundle has no destination port, use --dst to set it"))
+		}
+
+		if err := validatePort(strconv.Itoa(b.Jump.DstPort)); err != nil {
+			return reportError(cmd, fmt.Errorf("invalid destination port: %v", err))
+		}
+
+		jump, err := b.PortJump()
+		if err != nil {
+			return reportError(cmd, fmt.Errorf("invalid jump in bundle: %v", err))
+		}
+
+		hostName, _ := cmd.Flags().GetString("host")
+
+		var unchanged bool
+		var host *options.Host
+		err = opts.Update(func(o *options.Options) error {
+			jumps := &o.Jumps
+			if hostName != "" || b.Host != "" {
+				host, err = importHost(o, hostName, b.Host)
+				if err != nil {
+					return err
+				}
+				jumps = &host.Jumps
+			}
+
+			var existing *options.PortJump
+			for _, j := range *jumps {
+				if j.Name == jump.Name {
+					existing = j
+				}
+			}
+
+			if existing == nil {
+				*jumps = append(*jumps, jump)
+				return nil
+			}
+
+			if len(options.DiffJump(existing, jump)) == 0 {
+				unchanged = true
+				return nil
+			}
+
+			return fmt.Errorf("a different jump named %s already exists, use --name to import it under another name", jump.Name)
+		})
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		var hostResult string
+		if host != nil {
+			hostResult = host.Name
+		}
+
+		if output == outputJSON {
+			return writeJSON(struct {
+				Host     string     `json:"host,omitempty"`
+				Imported bool       `json:"imported"`
+				Jump     jumpResult `json:"jump"`
+			}{hostResult, !unchanged, newJumpResult(jump, false)})
+		}
+
+		if unchanged {
+			fmt.Printf("Jump %s is already configured, nothing to import.\n", jump.Name)
+			return nil
+		}
+
+		if host != nil {
+			fmt.Printf("Jump %s for port %d imported for host %s (%s).\n", jump.Name, jump.DstPort, host.Name, host.Address)
+			return nil
+		}
+
+		fmt.Printf("Jump %s for port %d imported.\n", jump.Name, jump.DstPort)
+
+		return nil
+	},
+}
+
+// importHost returns the host entry to import a jump into, by name or else
+// by address. A new host entry is added if there is none yet.
+func importHost(o *options.Options, name string, address string) (*options.Host, error) {
+	var host *options.Host
+	if name != "" {
+		host = o.Host(name)
+	} else {
+		host = o.HostByAddress(address)
+	}
+
+	if host != nil {
+		return host, nil
+	}
+
+	if name == "" {
+		name = address
+	}
+
+	if err := options.ValidateName(name); err != nil {
+		return nil, fmt.Errorf("cannot add a host named after %s, use --host to name it: %v", address, err)
+	}
+
+	if address == "" {
+		address = name
+	}
+
+	host = &options.Host{Name: name, Address: address}
+	o.Hosts = append(o.Hosts, host)
+
+	return host, nil
+}
+
+// readBundle reads the bundle to import from an otpauth:// URI argument, a
+// file, or stdin. Encrypted bundles are decrypted with a passphrase.
+func readBundle(cmd *cobra.Command, args []string) (*bundle.Bundle, error) {
+	if len(args) == 1 && bundle.IsURI(args[0]) {
+		return bundle.ParseURI(args[0])
+	}
+
+	var data []byte
+	var err error
+	if len(args) == 0 || args[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %v", err)
+	}
+
+	if bundle.IsURI(string(data)) {
+		return bundle.ParseURI(string(data))
+	}
+
+	passphrase, err := readPassphrase(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if passphrase == "" && bundle.IsEncrypted(data) {
+		passphrase, err = promptPassphrase(false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bundle.Unmarshal(data, passphrase)
+}
+
+func init() {
+	configCmd.AddCommand(importCmd)
+
+	importCmd.Flags().String("name", "", "Import the jump under a different name")
+	importCmd.Flags().String("host", "", "Import the jump into this host entry, by name or alias")
+	importCmd.Flags().Int("dst", 0, "Destination port of the jump, for URIs that do not carry one")
+	importCmd.Flags().String("passphrase-file", "", "Decrypt the bundle with the passphrase in a file")
+	addOutputFlag(importCmd)
+}
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"port-jump/internal/options"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
@@ -25,24 +27,46 @@ var listCmd = &cobra.Command{
 					return lipgloss.NewStyle().Padding(0, 1)
 				}
 			}).
-			Headers("Enabled", "Destination", "Interval")
+			Headers("Name", "Enabled", "Destination", "Interval", "Tags", "Description")
 
+		total := len(opts.Jumps)
 		for _, jump := range opts.Jumps {
-			t.Row(
-				styledBool(jump.Enabled),
-				fmt.Sprintf("%d", jump.DstPort),
-				fmt.Sprintf("%d", jump.Interval),
-			)
+			t.Row(jumpRow(jump.Name, jump)...)
+		}
+
+		// jumps of client host entries are listed as host/name
+		for _, host := range opts.Hosts {
+			total += len(host.Jumps)
+			for _, jump := range host.Jumps {
+				t.Row(jumpRow(host.Name+"/"+jump.Name, jump)...)
+			}
 		}
 
 		footer := lipgloss.NewStyle().Bold(true).
 			Foreground(lipgloss.Color("240")).
-			Render(fmt.Sprintf("Total jumps: %d", len(opts.Jumps)))
+			Render(fmt.Sprintf("Total jumps: %d", total))
 
 		fmt.Println(t.Render() + "\n" + footer)
 	},
 }
 
+// jumpRow returns the table row for jump, listed as name
+func jumpRow(name string, jump *options.PortJump) []string {
+	destination := fmt.Sprintf("%d", jump.DstPort)
+	if jump.Interface != "" {
+		destination = fmt.Sprintf("%d (%s)", jump.DstPort, jump.Interface)
+	}
+
+	return []string{
+		name,
+		styledBool(jump.Enabled),
+		destination,
+		fmt.Sprintf("%d", jump.Interval),
+		strings.Join(jump.Tags, ", "),
+		jump.Description,
+	}
+}
+
 func styledBool(value bool) string {
 	var (
 		trueStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))  // Green
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"port-jump/internal/bundle"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/cobra"
+)
+
+// otpauthCmd represents the otpauth command
+var otpauthCmd = &cobra.Command{
+	Use:   "otpauth [name]",
+	Short: "Show a jump as an otpauth URI and QR code",
+	Long: `Show a jump as an otpauth:// URI, and as a QR code on a terminal.
+
+The URI uses the format authenticator apps and password managers understand,
+with the jump's destination port, host and port range as extra parameters.
+Scan the QR code or copy the URI to move a jump to another device, and import
+it on a client with 'port-jump config import'.`,
+	Example: `  port-jump config otpauth ssh --host bastion.example.com
+  port-jump config otpauth -t web --qr=false`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selector := jumpSelector(cmd, args)
+		if selector.Empty() {
+			return reportError(cmd, errors.New("a jump name, tag or port needs to be specified"))
+		}
+
+		jump, err := opts.SelectOne(selector)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		// uris carry the resolved secret
+		if err := jump.ResolveErr(); err != nil {
+			return reportError(cmd, err)
+		}
+
+		host, _ := cmd.Flags().GetString("host")
+		uri := bundle.New(host, jump).URI()
+
+		// only render the QR code on a terminal, unless asked for explicitly
+		qr, _ := cmd.Flags().GetBool("qr")
+		if qr && (interactive() || cmd.Flags().Changed("qr")) {
+			code, err := qrcode.New(uri, qrcode.Medium)
+			if err != nil {
+				return reportError(cmd, fmt.Errorf("failed to render QR code: %v", err))
+			}
+
+			fmt.Print(code.ToSmallString(false))
+		}
+
+		fmt.Println(uri)
+
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(otpauthCmd)
+
+	addSelectorFlags(otpauthCmd)
+	otpauthCmd.Flags().String("host", "", "Host name or address clients use to reach the jump")
+	otpauthCmd.Flags().Bool("qr", true, "Render a QR code of the URI")
+}
@@ -1,79 +1,141 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"port-jump/internal/options"
 
 	"github.com/charmbracelet/huh"
-	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
 // toggleCmd represents the toggle command
 var toggleCmd = &cobra.Command{
-	Use:   "toggle",
+	Use:   "toggle [name]",
 	Short: "Toggle jump status",
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(opts.Jumps) == 0 {
-			fmt.Println("There are no configured jumps to toggle.")
-			return
+	Long: `Toggle jump status.
+
+Without a name, --tag or --port, a form is shown to select the jump to toggle.
+Use --enabled to set a specific state instead of toggling it.`,
+	Example: `  port-jump config toggle
+  port-jump config toggle ssh --yes
+  port-jump config toggle --tag web --enabled=false --yes --output json`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := outputFormat(cmd)
+		if err != nil {
+			return reportError(cmd, err)
 		}
 
-		selectOptions := make([]huh.Option[int], 0, len(opts.Jumps))
-		for _, jump := range opts.Jumps {
-			selectOptions = append(selectOptions, huh.NewOption(
-				fmt.Sprintf("Port %d, Interval %d (enabled: %s)", jump.DstPort, jump.Interval, styledBool(jump.Enabled)),
-				jump.DstPort,
-			))
+		if len(opts.Jumps) == 0 {
+			return reportError(cmd, errors.New("there are no configured jumps to toggle"))
 		}
 
-		var selectedJumpPort int
-		var confirm bool
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewSelect[int]().
-					Title("Select a jump").
-					Options(selectOptions...).
-					Value(&selectedJumpPort),
-				huh.NewConfirm().
-					Title("Are you sure you want to toggle this jump?").
-					Affirmative("Yes!").
-					Negative("No.").
-					Value(&confirm),
-			),
-		)
-
-		err := form.Run()
+		selector, err := selectJumps(cmd, args)
 		if err == huh.ErrUserAborted {
-			return
+			return notChanged(output, "Not toggling a jump.")
 		}
-
 		if err != nil {
-			log.Error().Err(err).Msg("failed to read form input")
-			return
+			return reportError(cmd, err)
 		}
 
-		if !confirm {
-			fmt.Println("Not toggling a jump.")
-			return
+		if err := confirmChange(cmd, "Are you sure you want to toggle this jump?"); err != nil {
+			if err == errNotConfirmed || err == huh.ErrUserAborted {
+				return notChanged(output, "Not toggling a jump.")
+			}
+			return reportError(cmd, err)
 		}
 
-		var newState bool
-		for _, jump := range opts.Jumps {
-			if jump.DstPort == selectedJumpPort {
-				jump.Enabled = !jump.Enabled
-				newState = jump.Enabled
+		setState := cmd.Flags().Changed("enabled")
+		state, _ := cmd.Flags().GetBool("enabled")
+
+		var toggled []*options.PortJump
+		err = opts.Update(func(o *options.Options) error {
+			toggled = o.Select(selector)
+			if len(toggled) == 0 {
+				return fmt.Errorf("no jumps match %s", selector)
 			}
+
+			for _, jump := range toggled {
+				if setState {
+					jump.Enabled = state
+				} else {
+					jump.Enabled = !jump.Enabled
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return reportError(cmd, fmt.Errorf("failed to save jump configuration: %v", err))
+		}
+
+		if output == outputJSON {
+			return writeJSON(newJumpResults(toggled))
 		}
 
-		if err := opts.Save(); err != nil {
-			log.Error().Err(err).Msg("failed to save jump configuration")
-			return
+		for _, jump := range toggled {
+			fmt.Printf("Jump %s toggled to %s.\n", jump.Name, styledBool(jump.Enabled))
 		}
 
-		fmt.Printf("Jump %d toggled to %s.\n", selectedJumpPort, styledBool(newState))
+		return nil
 	},
 }
 
+// selectJumps returns a selector for the jumps chosen with a name argument or
+// selector flags. Without those, a form is shown to select a single jump.
+func selectJumps(cmd *cobra.Command, args []string) (options.Selector, error) {
+	return chooseJumps(jumpSelector(cmd, args))
+}
+
+// chooseJumps checks that selector matches jumps. If selector is empty, a
+// form is shown to select a single jump instead.
+func chooseJumps(selector options.Selector) (options.Selector, error) {
+	if !selector.Empty() {
+		if len(opts.Select(selector)) == 0 {
+			return selector, fmt.Errorf("no jumps match %s", selector)
+		}
+
+		return selector, nil
+	}
+
+	if !interactive() {
+		return selector, errors.New("select a jump by name, --tag or --port when not running in a terminal")
+	}
+
+	var selectedJumpName string
+	err := huh.NewSelect[string]().
+		Title("Select a jump").
+		Options(jumpOptions(opts.Jumps)...).
+		Value(&selectedJumpName).
+		Run()
+	if err != nil {
+		return selector, err
+	}
+
+	return options.Selector{Name: selectedJumpName}, nil
+}
+
+// jumpOptions returns form select options for jumps, keyed by name
+func jumpOptions(jumps []*options.PortJump) []huh.Option[string] {
+	selectOptions := make([]huh.Option[string], 0, len(jumps))
+	for _, jump := range jumps {
+		selectOptions = append(selectOptions, huh.NewOption(
+			fmt.Sprintf("%s: Port %d, Interval %d (enabled: %s)", jump.Name, jump.DstPort, jump.Interval, styledBool(jump.Enabled)),
+			jump.Name,
+		))
+	}
+
+	return selectOptions
+}
+
 func init() {
 	configCmd.AddCommand(toggleCmd)
+
+	addSelectorFlags(toggleCmd)
+	toggleCmd.Flags().Bool("enabled", false, "Set the enabled state instead of toggling it")
+	toggleCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+	addOutputFlag(toggleCmd)
 }
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"port-jump/internal/options"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Validate a configuration file",
+	Long: `Validate a configuration file.
+
+Every jump is checked for decodable secrets, port and interval ranges,
+duplicate destinations and derived-port collisions. The configuration file
+and its drop-ins are checked for permissions that expose shared secrets.
+
+The command exits with a non-zero status if any errors are found.`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	// validate reads the configuration itself, so that invalid files can be
+	// reported on instead of failing to load, and no example is written out.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return rootCmdValidator(cmd)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := outputFormat(cmd)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		var (
+			source   string
+			problems []options.Problem
+		)
+
+		switch {
+		case len(args) > 0:
+			source = args[0]
+			problems = options.ValidateFile(source)
+		case opts.FromEnvironment():
+			source = "environment"
+			if err := opts.Load(); err != nil {
+				problems = []options.Problem{{Severity: options.SeverityError, Message: err.Error()}}
+			} else {
+				problems = opts.Validate()
+			}
+		default:
+			path, err := opts.ConfigPath()
+			if err != nil {
+				return reportError(cmd, err)
+			}
+			source = path
+			problems = options.ValidateFile(source)
+		}
+
+		valid := !options.HasErrors(problems)
+
+		if output == outputJSON {
+			if problems == nil {
+				problems = []options.Problem{}
+			}
+
+			if err := writeJSON(validateResult{Source: source, Valid: valid, Problems: problems}); err != nil {
+				return err
+			}
+		} else {
+			printProblems(source, problems)
+		}
+
+		// the problems are already reported, only the exit status is left
+		if !valid {
+			return errors.New("configuration is not valid")
+		}
+
+		return nil
+	},
+}
+
+// validateResult is the JSON output of the validate command
+type validateResult struct {
+	Source   string            `json:"source"`
+	Valid    bool              `json:"valid"`
+	Problems []options.Problem `json:"problems"`
+}
+
+// printProblems prints validation problems in a human readable format
+func printProblems(source string, problems []options.Problem) {
+	var (
+		errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196")) // Red
+		warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214")) // Orange
+		okStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))  // Green
+		fileStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	)
+
+	var errs, warnings int
+	for _, p := range problems {
+		label := warningStyle.Render("warning")
+		if p.Severity == options.SeverityError {
+			label = errorStyle.Render("error")
+			errs++
+		} else {
+			warnings++
+		}
+
+		fmt.Printf("%s %s %s\n", label, p.String(), fileStyle.Render("("+p.File+")"))
+	}
+
+	summary := fmt.Sprintf("%s: %d error(s), %d warning(s)", source, errs, warnings)
+	if errs == 0 {
+		fmt.Println(okStyle.Render(summary))
+		return
+	}
+
+	fmt.Println(errorStyle.Render(summary))
+}
+
+func init() {
+	configCmd.AddCommand(validateCmd)
+
+	addOutputFlag(validateCmd)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"port-jump/internal/client"
+	"port-jump/internal/options"
+	"strconv"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// connectCmd represents the connect command
+var connectCmd = &cobra.Command{
+	Use:   "connect <host> <dstport>",
+	Short: "Connect stdin and stdout to the current port of a jump",
+	Long: `Connect stdin and stdout to the current port of a jump.
+
+The host is resolved through host entries by name, alias or address, and the
+jump for the destination port is used. Without a matching host entry, the
+jump is selected from the configured jumps by port.
+
+This makes port-jump usable as an ssh ProxyCommand, so that scp, rsync, git
+and editors connecting over ssh work without knowing about jumps:
+
+  Host prod-bastion
+    ProxyCommand port-jump connect %h %p
+    HostKeyAlias prod-bastion`,
+	Example: `  ssh -o ProxyCommand='port-jump connect %h %p' user@prod-bastion
+  port-jump connect 10.211.55.6 22`,
+	Args:          cobra.ExactArgs(2),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dst, err := strconv.Atoi(args[1])
+		if err != nil {
+			return reportError(cmd, fmt.Errorf("invalid destination port %q", args[1]))
+		}
+
+		name, _ := cmd.Flags().GetString("jump")
+		selector := options.Selector{Name: name}
+		if name == "" {
+			selector.Port = dst
+		}
+
+		jump, _, address, err := resolveHost(args[0], selector)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		margin, _ := cmd.Flags().GetDuration("margin")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		ports, err := client.Candidates(jump, time.Now(), margin)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		conn, port, err := client.Dial(context.Background(), address, ports, timeout)
+		if err != nil {
+			return reportError(cmd, fmt.Errorf("failed to connect to %s port %d: %v", address, port, err))
+		}
+		defer conn.Close()
+
+		zlog.Debug().Str("address", address).Int("port", port).Str("jump", jump.Name).Msg("connected")
+
+		if err := client.Bridge(conn, os.Stdin, os.Stdout); err != nil {
+			return reportError(cmd, err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(connectCmd)
+
+	connectCmd.Flags().String("jump", "", "Name of the jump to use, instead of the jump for the destination port")
+	addMarginFlag(connectCmd.Flags())
+	connectCmd.Flags().Duration("timeout", 10*time.Second, "Timeout connecting to the host")
+}
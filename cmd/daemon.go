@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"port-jump/internal/options"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// daemon is a long running command that keeps firewall rules in line with
+// rotating ports, such as the jump and rewrite commands
+type daemon struct {
+	// what names the configuration the daemon acts on in log messages
+	what string
+	// configured reports if there is anything to start
+	configured func() bool
+	// start starts a goroutine per rule. the goroutines run until ctx is
+	// cancelled, which the returned WaitGroup can wait for.
+	start func(ctx context.Context) *sync.WaitGroup
+	// cleanup deletes every rule the daemon created
+	cleanup func() error
+}
+
+// run runs the daemon until SIGINT or SIGTERM, reloading the configuration
+// on SIGHUP. Unless --skip-cleanup is set, rules are deleted on exit.
+func (d *daemon) run(cmd *cobra.Command) {
+	skip, err := cmd.Flags().GetBool("skip-cleanup")
+	if err != nil {
+		log.Error().Err(err).Msg("failed to parse skip-cleanup configuration")
+		return
+	}
+
+	defer func() {
+		if skip {
+			return
+		}
+
+		if err := d.cleanup(); err != nil {
+			log.Error().Err(err).Msg("failed to cleanup firewall rules")
+		}
+	}()
+
+	// Channel to listen for termination signal
+	stopChan := make(chan os.Signal, 1)
+	signal.Notify(stopChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Channel to listen for configuration reloads
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+
+	if !d.configured() {
+		log.Error().Msgf("there are no enabled %s in the configuration file", d.what)
+		skip = true // dont do any cleanups, we didnt create anything.
+		return
+	}
+
+	// remove rules left behind by an earlier run, including rules without a
+	// name that would never be updated. there usually are none, and failures
+	// to add rules are reported when starting anyway.
+	if err := d.cleanup(); err != nil {
+		log.Debug().Err(err).Msg("no firewall rules to cleanup on startup")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := d.start(ctx)
+
+	for {
+		select {
+		case <-reloadChan:
+			log.Info().Msg("reloading configuration")
+
+			newOpts, err := opts.Reload()
+			if err != nil {
+				log.Error().Err(err).Msg("failed to reload configuration, keeping the current one")
+				continue
+			}
+
+			cancel()
+			wg.Wait()
+			opts = newOpts
+
+			// remove rules that may no longer be configured
+			if err := d.cleanup(); err != nil {
+				log.Warn().Err(err).Msg("failed to cleanup firewall rules before reload")
+			}
+
+			if !d.configured() {
+				log.Warn().Msgf("there are no enabled %s in the reloaded configuration file", d.what)
+			}
+
+			ctx, cancel = context.WithCancel(context.Background())
+			wg = d.start(ctx)
+
+		// block until we need to leave
+		case <-stopChan:
+			cancel()
+			wg.Wait()
+			log.Info().Msg("exiting")
+			return
+		}
+	}
+}
+
+// rotate calls onChange with the port of jump every time it changes, until
+// ctx is cancelled. Errors generating ports are logged to logger, and stop
+// the rotation.
+func rotate(ctx context.Context, jump *options.PortJump, logger zerolog.Logger, onChange func(port int)) {
+	portGen, err := jump.Totp()
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to get port generator for jump")
+		return
+	}
+
+	var port = 0
+
+	for {
+		newPort, err := portGen.GenerateTCPPort()
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to get a tcp port from portGen")
+			return
+		}
+
+		if newPort != port {
+			port = newPort
+			onChange(port)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Millisecond * 500):
+		}
+	}
+}
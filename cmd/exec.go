@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"port-jump/internal/client"
+	"strconv"
+	"strings"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:   "exec [flags] [--] command [arguments]",
+	Short: "Run a command with the current port of a jump",
+	Long: `Run a command with the current port of a jump.
+
+The placeholders {host}, {port} and {uri} in the command, its arguments and
+the values of --env are replaced with the address, the current port, and a
+URI of the form <uri>address:port/. The address is the one of --host, unless
+--address is given.
+
+If the command fails close to a window boundary, and the port it used now
+refuses connections, it is run again with the adjacent window's port, so
+that small clock differences do not break the command. Use --no-retry for
+commands that are not safe to run twice.`,
+	Example: `  port-jump exec --host db1 -p 5432 -- psql -h {host} -p {port} app
+  port-jump exec --host prod -p 22 -- rsync -e 'ssh -p {port}' ./site/ {host}:/srv/site/
+  port-jump exec --host prod -p 443 --env API_URL={uri} -- ./deploy.sh`,
+	Args:          cobra.MinimumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// the command is not a jump name, so only flags select the jump
+		if jumpSelector(cmd, nil).Empty() {
+			return reportError(cmd, errors.New("a jump name, tag or port needs to be specified"))
+		}
+
+		jump, host, err := selectJump(cmd, nil)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		address, _ := cmd.Flags().GetString("address")
+		if address == "" && host != nil {
+			address = host.Address
+		}
+
+		uri, _ := cmd.Flags().GetString("uri")
+		env, _ := cmd.Flags().GetStringArray("env")
+		margin, _ := cmd.Flags().GetDuration("margin")
+		timeout, _ := cmd.Flags().GetDuration("probe-timeout")
+		noRetry, _ := cmd.Flags().GetBool("no-retry")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		for _, value := range env {
+			if !strings.Contains(value, "=") {
+				return reportError(cmd, fmt.Errorf("invalid --env %q, expected NAME=VALUE", value))
+			}
+		}
+
+		if address == "" && (usesAddress(args) || usesAddress(env)) {
+			return reportError(cmd, errors.New("{host} and {uri} need an address, use --host or --address"))
+		}
+
+		ports, err := client.Candidates(jump, time.Now(), margin)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		// only retry when there is an address to check the port was refused on
+		if noRetry || dryRun || address == "" {
+			ports = ports[:1]
+		}
+
+		var code int
+		for i, port := range ports {
+			replacer, err := templateReplacer(address, port, uri)
+			if err != nil {
+				return reportError(cmd, err)
+			}
+
+			execArgs := make([]string, len(args))
+			for j, arg := range args {
+				execArgs[j] = replacer.Replace(arg)
+			}
+
+			execEnv := make([]string, len(env))
+			for j, value := range env {
+				execEnv[j] = replacer.Replace(value)
+			}
+
+			if dryRun {
+				fmt.Println(strings.Join(append(execEnv, execArgs...), " "))
+				return nil
+			}
+
+			code = runCommand(execArgs[0], execArgs[1:], execEnv...)
+			if code == 0 || i == len(ports)-1 || !portRefused(address, port, timeout) {
+				break
+			}
+
+			zlog.Warn().Int("port", port).Int("next", ports[i+1]).Int("code", code).Msg("command failed and port is refused, retrying with the adjacent window's port")
+		}
+
+		os.Exit(code)
+		return nil
+	},
+}
+
+// templateReplacer returns a replacer for the placeholders of the exec
+// command. {uri} is built like the get uri command does, and only replaced
+// when there is an address.
+func templateReplacer(address string, port int, scheme string) (*strings.Replacer, error) {
+	replacements := []string{
+		"{host}", address,
+		"{port}", strconv.Itoa(port),
+	}
+
+	if address != "" {
+		uri, err := buildURI(scheme, address, port)
+		if err != nil {
+			return nil, err
+		}
+
+		replacements = append(replacements, "{uri}", uri)
+	}
+
+	return strings.NewReplacer(replacements...), nil
+}
+
+// usesAddress reports if any of values uses a placeholder needing an address
+func usesAddress(values []string) bool {
+	for _, value := range values {
+		if strings.Contains(value, "{host}") || strings.Contains(value, "{uri}") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// portRefused reports if connecting to port on address is refused
+func portRefused(address string, port int, timeout time.Duration) bool {
+	if timeout <= 0 {
+		return false
+	}
+
+	conn, _, err := client.Dial(context.Background(), address, []int{port}, timeout)
+	if err != nil {
+		return client.Refused(err)
+	}
+	conn.Close()
+
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	// everything from the command on is passed to the command
+	execCmd.Flags().SetInterspersed(false)
+
+	addSelectorFlags(execCmd)
+	addHostFlag(execCmd)
+	addProbeFlags(execCmd.Flags())
+	execCmd.Flags().String("address", "", "Address used for {host} and {uri}. Defaults to the address of --host")
+	execCmd.Flags().String("uri", "https://", "The URI handler used for {uri}")
+	execCmd.Flags().StringArray("env", nil, "Environment variable to set for the command, as NAME=VALUE. Can be repeated")
+	execCmd.Flags().Bool("no-retry", false, "Do not run the command again with the adjacent window's port")
+	execCmd.Flags().Bool("dry-run", false, "Print the command instead of running it")
+}
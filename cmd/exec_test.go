@@ -0,0 +1,26 @@
+package cmd
+
+import "testing"
+
+func TestTemplateReplacer(t *testing.T) {
+	replacer, err := templateReplacer("2001:db8::1", 4000, "https://")
+	if err != nil {
+		t.Fatalf("templateReplacer() error = %v", err)
+	}
+
+	got := replacer.Replace("curl -H 'Host: {host}' {uri}health --local-port {port}")
+	want := "curl -H 'Host: 2001:db8::1' https://[2001:db8::1]:4000/health --local-port 4000"
+	if got != want {
+		t.Errorf("Replace() = %q, want %q", got, want)
+	}
+
+	// without an address only the port can be used
+	replacer, err = templateReplacer("", 4000, "https://")
+	if err != nil {
+		t.Fatalf("templateReplacer() without an address error = %v", err)
+	}
+
+	if got := replacer.Replace("nc -l {port}"); got != "nc -l 4000" {
+		t.Errorf("Replace() = %q, want %q", got, "nc -l 4000")
+	}
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/signal"
+	"port-jump/internal/client"
+	"port-jump/internal/options"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// forwardCmd represents the forward command
+var forwardCmd = &cobra.Command{
+	Use:   "forward [name]",
+	Short: "Forward a stable local port to the current port of a jump",
+	Long: `Forward a stable local port to the current port of a jump.
+
+Tools that can only connect to a fixed host and port, such as database GUIs,
+browsers or vendor agents, can connect to the local listener instead. Every
+new connection is sent to the host's current port. Established connections
+are not affected by port changes.
+
+The host is resolved through host entries by name, alias or address. Without
+a matching host entry, the jump is selected from the configured jumps and the
+host is used as the address. The configuration is reloaded on SIGHUP.`,
+	Example: `  port-jump forward --listen 127.0.0.1:2222 --host db1 -p 5432
+  port-jump forward --listen localhost:8443 --host 10.211.55.6 web`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, _ := cmd.Flags().GetString("listen")
+		hostName, _ := cmd.Flags().GetString("host")
+		margin, _ := cmd.Flags().GetDuration("margin")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		if listen == "" || hostName == "" {
+			return reportError(cmd, errors.New("--listen and --host are required"))
+		}
+
+		selector := jumpSelector(cmd, args)
+		if selector.Empty() {
+			return reportError(cmd, errors.New("a jump name, tag or port needs to be specified"))
+		}
+
+		target, err := newForwardTarget(hostName, selector)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		listener, err := net.Listen("tcp", listen)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		go func() {
+			<-ctx.Done()
+			listener.Close()
+		}()
+
+		// Channel to listen for configuration reloads
+		reloadChan := make(chan os.Signal, 1)
+		signal.Notify(reloadChan, syscall.SIGHUP)
+		defer signal.Stop(reloadChan)
+
+		go func() {
+			for range reloadChan {
+				log.Info().Msg("reloading configuration")
+				if err := target.reload(hostName, selector); err != nil {
+					log.Error().Err(err).Msg("failed to reload configuration, keeping the current one")
+				}
+			}
+		}()
+
+		jump, address := target.get()
+		log.Info().Str("listen", listener.Addr().String()).Str("address", address).Str("jump", jump.Name).
+			Int("dstport", jump.DstPort).Msg("forwarding")
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+
+				log.Error().Err(err).Msg("failed to accept connection")
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+
+			go target.forward(ctx, conn, margin, timeout)
+		}
+
+		// established connections are dropped on exit
+		log.Info().Msg("exiting")
+
+		return nil
+	},
+}
+
+// forwardTarget is the jump and address connections are forwarded to
+type forwardTarget struct {
+	mu      sync.Mutex
+	jump    *options.PortJump
+	address string
+}
+
+// newForwardTarget resolves the jump and address to forward to
+func newForwardTarget(host string, selector options.Selector) (*forwardTarget, error) {
+	jump, _, address, err := resolveHost(host, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &forwardTarget{jump: jump, address: address}, nil
+}
+
+// get returns the current jump and address
+func (t *forwardTarget) get() (*options.PortJump, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.jump, t.address
+}
+
+// reload reloads the configuration and resolves the target again
+func (t *forwardTarget) reload(host string, selector options.Selector) error {
+	newOpts, err := opts.Reload()
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previous := opts
+	opts = newOpts
+
+	jump, _, address, err := resolveHost(host, selector)
+	if err != nil {
+		opts = previous
+		return err
+	}
+
+	t.jump, t.address = jump, address
+
+	return nil
+}
+
+// forward connects conn to the current port of the target
+func (t *forwardTarget) forward(ctx context.Context, conn net.Conn, margin, timeout time.Duration) {
+	defer conn.Close()
+
+	jump, address := t.get()
+
+	ports, err := client.Candidates(jump, time.Now(), margin)
+	if err != nil {
+		log.Error().Err(err).Str("jump", jump.Name).Msg("failed to generate ports")
+		return
+	}
+
+	remote, port, err := client.Dial(ctx, address, ports, timeout)
+	if err != nil {
+		log.Warn().Err(err).Str("client", conn.RemoteAddr().String()).Int("port", port).Msg("failed to connect")
+		return
+	}
+	defer remote.Close()
+
+	log.Debug().Str("client", conn.RemoteAddr().String()).Str("address", address).Int("port", port).Msg("forwarding connection")
+
+	if err := client.Bridge(remote, conn, conn); err != nil {
+		log.Debug().Err(err).Str("client", conn.RemoteAddr().String()).Msg("connection closed")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(forwardCmd)
+
+	forwardCmd.Flags().String("listen", "", "Local address to listen on, i.e. 127.0.0.1:2222")
+	forwardCmd.Flags().String("host", "", "Name, alias or address of the host to forward to")
+	addMarginFlag(forwardCmd.Flags())
+	forwardCmd.Flags().Duration("timeout", 10*time.Second, "Timeout connecting to the host")
+	addSelectorFlags(forwardCmd)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"port-jump/internal/client"
+	"port-jump/internal/options"
+)
+
+func TestForwardFallsBackToAdjacentWindow(t *testing.T) {
+	// with a margin as long as the interval, the previous window's port is
+	// always tried after the current one
+	jump := &options.PortJump{Name: "ssh", Enabled: true, DstPort: 22, Interval: 3600, SharedSecret: "JBSWY3DPEHPK3PXP"}
+	margin := time.Hour
+
+	ports, err := client.Candidates(jump, time.Now(), margin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ports) != 2 {
+		t.Fatalf("Candidates() = %v, want the current and previous window's port", ports)
+	}
+
+	// the server still listens on the previous window's port
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(ports[1])))
+	if err != nil {
+		t.Skipf("previous window's port %d is not available: %v", ports[1], err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		io.Copy(conn, conn)
+	}()
+
+	local, remote := net.Pipe()
+	defer local.Close()
+
+	target := &forwardTarget{jump: jump, address: "127.0.0.1"}
+	go target.forward(context.Background(), remote, margin, time.Second)
+
+	local.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := local.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(local, reply); err != nil {
+		t.Fatalf("no reply through the forwarder: %v", err)
+	}
+
+	if string(reply) != "ping" {
+		t.Errorf("reply = %q, want ping", reply)
+	}
+}
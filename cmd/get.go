@@ -1,15 +1,114 @@
 package cmd
 
 import (
+	"fmt"
+	"port-jump/internal/options"
+	"port-jump/pkg/hotp"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
 )
 
+const (
+	outputEnv   = "env"
+	outputShell = "shell"
+)
+
 // getCmd represents the generate command
 var getCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get port output to use in other programs",
 }
 
+// portResult is the current port of a jump, in get command output
+type portResult struct {
+	Host        string    `json:"host,omitempty"`
+	Jump        string    `json:"jump"`
+	DstPort     int       `json:"dstport"`
+	Port        int       `json:"port"`
+	URI         string    `json:"uri,omitempty"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	Remaining   int       `json:"remaining"`
+}
+
+// newPortResult returns the port of jump for the window t falls in
+func newPortResult(jump *options.PortJump, host *options.Host, totp *hotp.Hotp, t time.Time) (*portResult, error) {
+	port, err := totp.GenerateTCPPortAt(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TCP port: %v", err)
+	}
+
+	start, end := totp.Window(t)
+
+	r := &portResult{
+		Jump:        jump.Name,
+		DstPort:     jump.DstPort,
+		Port:        port,
+		WindowStart: start.UTC(),
+		WindowEnd:   end.UTC(),
+		Remaining:   int(end.Sub(t).Seconds()),
+	}
+
+	if host != nil {
+		r.Host = host.Name
+	}
+
+	return r, nil
+}
+
+// variables returns the result as environment variables, in a stable order
+func (r *portResult) variables() [][2]string {
+	vars := [][2]string{
+		{"PORT_JUMP_HOST", r.Host},
+		{"PORT_JUMP_JUMP", r.Jump},
+		{"PORT_JUMP_DSTPORT", strconv.Itoa(r.DstPort)},
+		{"PORT_JUMP_PORT", strconv.Itoa(r.Port)},
+	}
+
+	if r.URI != "" {
+		vars = append(vars, [2]string{"PORT_JUMP_URI", r.URI})
+	}
+
+	return append(vars,
+		[2]string{"PORT_JUMP_WINDOW_START", strconv.FormatInt(r.WindowStart.Unix(), 10)},
+		[2]string{"PORT_JUMP_WINDOW_END", strconv.FormatInt(r.WindowEnd.Unix(), 10)},
+		[2]string{"PORT_JUMP_REMAINING", strconv.Itoa(r.Remaining)},
+	)
+}
+
+// writePortResult writes r in format. text is written for the text format.
+func writePortResult(format string, r *portResult, text string) error {
+	switch format {
+	case outputJSON:
+		return writeJSON(r)
+	case outputEnv:
+		for _, v := range r.variables() {
+			fmt.Printf("%s=%s\n", v[0], v[1])
+		}
+	case outputShell:
+		for _, v := range r.variables() {
+			fmt.Printf("export %s=%s\n", v[0], shellQuote(v[1]))
+		}
+	default:
+		fmt.Println(text)
+	}
+
+	return nil
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// addGetOutputFlag adds the --output flag of get commands to cmd
+func addGetOutputFlag(cmd *cobra.Command) {
+	addOutputFlag(cmd, outputText, outputJSON, outputEnv, outputShell)
+}
+
 func init() {
 	rootCmd.AddCommand(getCmd)
 }
@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"port-jump/internal/options"
-	"port-jump/pkg/hotp"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -14,51 +19,142 @@ import (
 var portCmd = &cobra.Command{
 	Use:   "port",
 	Short: "Generate an SSH port to use.",
+	Long: `Generate an SSH port to use.
+
+With --output json, env or shell, the port is printed with the window it is
+valid for, the seconds remaining in that window, and the jump it belongs to.
+Errors are reported in the same format, and exit with a non-zero status.
+
+With --follow, the port is printed again every time the window changes, until
+interrupted. JSON output then has one object per line.`,
+	Example: `  port-jump get port -p 22
+  port-jump get port -p 22 --follow -o json
+  port-jump get port --host prod ssh -o json
+  eval "$(port-jump get port -p 443 -o shell)"`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if err := portCmdValidator(cmd); err != nil {
-			return err
+		if err := portCmdValidator(cmd, args); err != nil {
+			return reportError(cmd, err)
 		}
 		return nil
 	},
-	Run: func(cmd *cobra.Command, args []string) {
-		target, _ := cmd.Flags().GetInt("port")
-		var j *options.PortJump
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := outputFormat(cmd)
 
-		for _, jump := range opts.Jumps {
-			if jump.DstPort == target {
-				j = jump
-			}
+		j, host, err := selectJump(cmd, args)
+		if err != nil {
+			return reportError(cmd, err)
 		}
 
-		if j == nil {
-			log.Error().Int("target", target).Msg("no configuration matching target found")
-			return
+		totp, err := j.Totp()
+		if err != nil {
+			return reportError(cmd, fmt.Errorf("failed to get totp generator handle: %v", err))
 		}
 
-		totp, err := hotp.NewTotp(j.SharedSecret, j.Interval)
-		if err != nil {
-			log.Error().Err(err).Msg("failed to get totp generator handle")
-			return
+		minRemaining, _ := cmd.Flags().GetDuration("min-remaining")
+		showRemaining, _ := cmd.Flags().GetBool("remaining")
+
+		if minRemaining >= time.Duration(j.Interval)*time.Second {
+			return reportError(cmd, fmt.Errorf("--min-remaining %v has to be shorter than the jump's interval of %ds", minRemaining, j.Interval))
 		}
 
-		port, err := totp.GenerateTCPPort()
-		if err != nil {
-			log.Error().Err(err).Msg("failed to generate TCP port")
-			return
+		follow, _ := cmd.Flags().GetBool("follow")
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		now := time.Now()
+		_, end := totp.Window(now)
+
+		// wait for the next window if the current one is about to end
+		if wait := windowWait(now, end, minRemaining); wait > 0 {
+			log.Debug().Dur("remaining", wait).Msg("waiting for the next window")
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(wait):
+			}
+
+			now = windowAfter(time.Now(), end)
 		}
 
-		fmt.Printf("%d\n", port)
+		for {
+			result, err := newPortResult(j, host, totp, now)
+			if err != nil {
+				return reportError(cmd, err)
+			}
+
+			text := strconv.Itoa(result.Port)
+			if showRemaining {
+				text += " " + strconv.Itoa(result.Remaining)
+			}
+
+			if follow && format == outputJSON {
+				// one line per window, so that every port can be read as it comes
+				if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+					return err
+				}
+			} else if err := writePortResult(format, result, text); err != nil {
+				return err
+			}
+
+			if !follow {
+				return nil
+			}
+
+			// sleep until the window ends, instead of polling for a new port
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Until(result.WindowEnd)):
+			}
+
+			now = windowAfter(time.Now(), result.WindowEnd)
+		}
 	},
 }
 
-func portCmdValidator(cmd *cobra.Command) error {
-	port, err := cmd.Flags().GetInt("port")
+// windowWait returns how long to wait for the next window, when less than
+// minRemaining is left of the window ending at end. It is 0 when there is no
+// need to wait.
+func windowWait(now time.Time, end time.Time, minRemaining time.Duration) time.Duration {
+	if remaining := end.Sub(now); remaining < minRemaining {
+		return remaining
+	}
+
+	return 0
+}
+
+// windowAfter returns the time to derive the port of the window after the one
+// ending at end. Timers may fire just before end, which would derive the port
+// of the ending window again, so the result is never before end.
+func windowAfter(now time.Time, end time.Time) time.Time {
+	if now.Before(end) {
+		return end
+	}
+
+	return now
+}
+
+func portCmdValidator(cmd *cobra.Command, args []string) error {
+	if _, err := outputFormat(cmd); err != nil {
+		return err
+	}
+
+	if jumpSelector(cmd, args).Empty() {
+		return errors.New("a jump name, tag or port needs to be specified")
+	}
+
+	minRemaining, err := cmd.Flags().GetDuration("min-remaining")
 	if err != nil {
 		return err
 	}
 
-	if port == 0 {
-		return errors.New("port needs to be specified")
+	if minRemaining < 0 {
+		return errors.New("--min-remaining cannot be negative")
 	}
 
 	return nil
@@ -67,5 +163,10 @@ func portCmdValidator(cmd *cobra.Command) error {
 func init() {
 	getCmd.AddCommand(portCmd)
 
-	portCmd.Flags().IntP("port", "p", 0, "Destination port to use from configuration file")
+	addSelectorFlags(portCmd)
+	addHostFlag(portCmd)
+	addGetOutputFlag(portCmd)
+	portCmd.Flags().Duration("min-remaining", 0, "Wait for the next window if less than this is left of the current one")
+	portCmd.Flags().Bool("remaining", false, "Also print the seconds remaining in the window, after the port")
+	portCmd.Flags().BoolP("follow", "f", false, "Print the port again every time the window changes")
 }
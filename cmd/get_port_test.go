@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"port-jump/internal/options"
+	"port-jump/pkg/hotp"
+)
+
+func TestWindowWait(t *testing.T) {
+	end := time.Unix(1_700_000_040, 0)
+
+	tests := []struct {
+		name         string
+		remaining    time.Duration
+		minRemaining time.Duration
+		want         time.Duration
+	}{
+		{name: "no minimum", remaining: time.Second},
+		{name: "enough left", remaining: 20 * time.Second, minRemaining: 10 * time.Second},
+		{name: "exactly the minimum", remaining: 10 * time.Second, minRemaining: 10 * time.Second},
+		{name: "too little left", remaining: 3 * time.Second, minRemaining: 10 * time.Second, want: 3 * time.Second},
+		{name: "just before the boundary", remaining: time.Millisecond, minRemaining: time.Second, want: time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := windowWait(end.Add(-tt.remaining), end, tt.minRemaining); got != tt.want {
+				t.Errorf("windowWait() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPortResultRemaining(t *testing.T) {
+	jump := &options.PortJump{Name: "ssh", DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"}
+
+	totp, err := hotp.NewTotp(jump.SharedSecret, jump.Interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a window starts every 30 seconds since the epoch
+	start := time.Unix(1_700_000_010, 0)
+
+	tests := []struct {
+		elapsed time.Duration
+		want    int
+	}{
+		{elapsed: 0, want: 30},
+		{elapsed: 1500 * time.Millisecond, want: 28},
+		{elapsed: 29 * time.Second, want: 1},
+	}
+
+	for _, tt := range tests {
+		r, err := newPortResult(jump, nil, totp, start.Add(tt.elapsed))
+		if err != nil {
+			t.Fatalf("newPortResult() error = %v", err)
+		}
+
+		if r.Remaining != tt.want {
+			t.Errorf("newPortResult() %v into the window remaining = %d, want %d", tt.elapsed, r.Remaining, tt.want)
+		}
+
+		if !r.WindowStart.Equal(start) || !r.WindowEnd.Equal(start.Add(30*time.Second)) {
+			t.Errorf("newPortResult() window = %v - %v, want the window starting at %v", r.WindowStart, r.WindowEnd, start)
+		}
+	}
+}
+
+func TestFollowWindows(t *testing.T) {
+	jump := &options.PortJump{Name: "ssh", DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"}
+
+	totp, err := hotp.NewTotp(jump.SharedSecret, jump.Interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Unix(1_700_000_010, 0)
+
+	// timers firing early, on time or late all move on to the next window
+	for _, fired := range []time.Duration{-5 * time.Millisecond, 0, 250 * time.Millisecond} {
+		now := start.Add(7 * time.Second)
+
+		for window := 1; window <= 3; window++ {
+			result, err := newPortResult(jump, nil, totp, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			now = windowAfter(result.WindowEnd.Add(fired), result.WindowEnd)
+
+			next, err := newPortResult(jump, nil, totp, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !next.WindowStart.Equal(result.WindowEnd) {
+				t.Errorf("timer fired %v from the boundary: window %d starts at %v, want %v", fired, window, next.WindowStart, result.WindowEnd)
+			}
+
+			wantStart := start.Add(time.Duration(window) * 30 * time.Second)
+			if !next.WindowStart.Equal(wantStart) {
+				t.Errorf("timer fired %v from the boundary: window %d starts at %v, want it aligned to %v", fired, window, next.WindowStart, wantStart)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestWritePortResult(t *testing.T) {
+	result := &portResult{
+		Host:        "prod",
+		Jump:        "web",
+		DstPort:     443,
+		Port:        4000,
+		URI:         "https://it's.example.com:4000/",
+		WindowStart: time.Unix(1_700_000_010, 0).UTC(),
+		WindowEnd:   time.Unix(1_700_000_040, 0).UTC(),
+		Remaining:   12,
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: outputText,
+			want:   "4000 12\n",
+		},
+		{
+			format: outputEnv,
+			want: "PORT_JUMP_HOST=prod\nPORT_JUMP_JUMP=web\nPORT_JUMP_DSTPORT=443\nPORT_JUMP_PORT=4000\n" +
+				"PORT_JUMP_URI=https://it's.example.com:4000/\nPORT_JUMP_WINDOW_START=1700000010\n" +
+				"PORT_JUMP_WINDOW_END=1700000040\nPORT_JUMP_REMAINING=12\n",
+		},
+		{
+			format: outputShell,
+			want: "export PORT_JUMP_HOST='prod'\nexport PORT_JUMP_JUMP='web'\nexport PORT_JUMP_DSTPORT='443'\n" +
+				"export PORT_JUMP_PORT='4000'\nexport PORT_JUMP_URI='https://it'\\''s.example.com:4000/'\n" +
+				"export PORT_JUMP_WINDOW_START='1700000010'\nexport PORT_JUMP_WINDOW_END='1700000040'\n" +
+				"export PORT_JUMP_REMAINING='12'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got := captureStdout(t, func() {
+				if err := writePortResult(tt.format, result, "4000 12"); err != nil {
+					t.Errorf("writePortResult() error = %v", err)
+				}
+			})
+
+			if got != tt.want {
+				t.Errorf("writePortResult() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWritePortResultJSON(t *testing.T) {
+	result := &portResult{
+		Jump:        "ssh",
+		DstPort:     22,
+		Port:        4000,
+		WindowStart: time.Unix(1_700_000_010, 0).UTC(),
+		WindowEnd:   time.Unix(1_700_000_040, 0).UTC(),
+		Remaining:   12,
+	}
+
+	out := captureStdout(t, func() {
+		writePortResult(outputJSON, result, "")
+	})
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &fields); err != nil {
+		t.Fatalf("writePortResult() output %q is not JSON: %v", out, err)
+	}
+
+	want := map[string]interface{}{
+		"jump":         "ssh",
+		"dstport":      float64(22),
+		"port":         float64(4000),
+		"window_start": "2023-11-14T22:13:30Z",
+		"window_end":   "2023-11-14T22:14:00Z",
+		"remaining":    float64(12),
+	}
+
+	if len(fields) != len(want) {
+		t.Errorf("writePortResult() fields = %v, want %v", fields, want)
+	}
+
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("writePortResult() %s = %v, want %v", key, fields[key], value)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"":          "''",
+		"prod":      "'prod'",
+		"it's":      `'it'\''s'`,
+		"$(reboot)": "'$(reboot)'",
+	}
+
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
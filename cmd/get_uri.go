@@ -3,10 +3,13 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"port-jump/internal/options"
-	"port-jump/pkg/hotp"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
@@ -14,48 +17,126 @@ import (
 var uriCmd = &cobra.Command{
 	Use:   "uri",
 	Short: "Generate a URI to use",
+	Long: `Generate a URI to use.
+
+--url takes a host, or a complete URL such as https://user@host/path?q=1,
+which has its port replaced with the current port. Hosts are prefixed with
+--uri. For schemes that do not put the port in the host, use {port} in --url
+as a placeholder, and {host} for the address of --host, which is bracketed
+if it is an IPv6 address.
+
+With --output json, env or shell, the URI is printed with the port, the
+window it is valid for, the seconds remaining in that window, and the jump
+it belongs to. Errors are reported in the same format, and exit with a
+non-zero status.`,
+	Example: `  port-jump get uri --url remote-service.local -p 443
+  port-jump get uri --url 'https://user@[2001:db8::1]/api?q=1' -p 443
+  port-jump get uri --host db1 --url 'postgres://app@{host}:{port}/app' -p 5432`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if err := uriCmdValidator(cmd); err != nil {
-			return err
+		if err := uriCmdValidator(cmd, args); err != nil {
+			return reportError(cmd, err)
 		}
 		return nil
 	},
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := outputFormat(cmd)
 
-		target, _ := cmd.Flags().GetInt("port")
-		var j *options.PortJump
-
-		for _, jump := range opts.Jumps {
-			if jump.DstPort == target {
-				j = jump
-			}
-		}
-
-		if j == nil {
-			log.Error().Int("target", target).Msg("no configuration matching target found")
-			return
+		j, host, err := selectJump(cmd, args)
+		if err != nil {
+			return reportError(cmd, err)
 		}
 
 		uri, _ := cmd.Flags().GetString("uri")
 		url, _ := cmd.Flags().GetString("url")
+		url, err = expandHost(url, host)
+		if err != nil {
+			return reportError(cmd, err)
+		}
 
-		totp, err := hotp.NewTotp(j.SharedSecret, j.Interval)
+		totp, err := j.Totp()
 		if err != nil {
-			log.Error().Err(err).Msg("failed to get totp generator handle")
-			return
+			return reportError(cmd, fmt.Errorf("failed to get totp generator handle: %v", err))
 		}
 
-		port, err := totp.GenerateTCPPort()
+		result, err := newPortResult(j, host, totp, time.Now())
 		if err != nil {
-			log.Error().Err(err).Msg("failed to generate TCP port")
-			return
+			return reportError(cmd, err)
 		}
 
-		fmt.Printf("%s%s:%d/\n", uri, url, port)
+		result.URI, err = buildURI(uri, url, result.Port)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		return writePortResult(format, result, result.URI)
 	},
 }
 
-func uriCmdValidator(cmd *cobra.Command) error {
+// expandHost replaces {host} in target with the address of host, bracketing
+// IPv6 addresses. An empty target defaults to the address of host.
+func expandHost(target string, host *options.Host) (string, error) {
+	if host == nil {
+		if strings.Contains(target, "{host}") {
+			return "", errors.New("--url uses {host}, which needs a --host")
+		}
+
+		return target, nil
+	}
+
+	if target == "" {
+		return host.Address, nil
+	}
+
+	// net.JoinHostPort brackets IPv6 addresses, the empty port is trimmed again
+	address := strings.TrimSuffix(net.JoinHostPort(host.Address, ""), ":")
+
+	return strings.ReplaceAll(target, "{host}", address), nil
+}
+
+// buildURI returns target with port as its port. A target containing {port}
+// is a template, and only has the placeholder replaced. A target with a scheme
+// is parsed as a URL, and its port replaced or added, keeping the userinfo,
+// path, query and fragment. Anything else is a host, or host and path, and
+// prefixed with scheme.
+func buildURI(scheme string, target string, port int) (string, error) {
+	if strings.Contains(target, "{port}") {
+		return strings.ReplaceAll(target, "{port}", strconv.Itoa(port)), nil
+	}
+
+	if !strings.Contains(target, "://") {
+		// bare IPv6 literals need brackets to be parsed as a host
+		if ip := net.ParseIP(target); ip != nil && ip.To4() == nil {
+			target = "[" + target + "]"
+		}
+
+		target = scheme + target
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %v", err)
+	}
+
+	if u.Host == "" {
+		return "", fmt.Errorf("url %s has no host, use {port} for urls without one", target)
+	}
+
+	u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(port))
+	if u.Path == "" && u.Opaque == "" {
+		u.Path = "/"
+	}
+
+	return u.String(), nil
+}
+
+func uriCmdValidator(cmd *cobra.Command, args []string) error {
+	if _, err := outputFormat(cmd); err != nil {
+		return err
+	}
+
 	uri, err := cmd.Flags().GetString("uri")
 	if err != nil {
 		return err
@@ -70,17 +151,17 @@ func uriCmdValidator(cmd *cobra.Command) error {
 		return err
 	}
 
-	if url == "" {
-		return errors.New("--url cannot be empty")
-	}
-
-	port, err := cmd.Flags().GetInt("port")
+	host, err := cmd.Flags().GetString("host")
 	if err != nil {
 		return err
 	}
 
-	if port == 0 {
-		return errors.New("port needs to be specified")
+	if url == "" && host == "" {
+		return errors.New("--url cannot be empty, unless a --host is used")
+	}
+
+	if jumpSelector(cmd, args).Empty() {
+		return errors.New("a jump name, tag or port needs to be specified")
 	}
 
 	return nil
@@ -89,7 +170,9 @@ func uriCmdValidator(cmd *cobra.Command) error {
 func init() {
 	getCmd.AddCommand(uriCmd)
 
-	uriCmd.PersistentFlags().StringP("uri", "", "https://", "The URI handler to use.")
-	uriCmd.PersistentFlags().StringP("url", "", "", "The URL to use.")
-	uriCmd.Flags().IntP("port", "p", 0, "Destination port to use from configuration file")
+	uriCmd.PersistentFlags().StringP("uri", "", "https://", "The URI handler to use for --url values without a scheme.")
+	uriCmd.PersistentFlags().StringP("url", "", "", "The host, URL or {port} template to use. Defaults to the address of --host.")
+	addSelectorFlags(uriCmd)
+	addHostFlag(uriCmd)
+	addGetOutputFlag(uriCmd)
 }
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"port-jump/internal/options"
+)
+
+func TestBuildURI(t *testing.T) {
+	tests := []struct {
+		scheme string
+		target string
+		want   string
+	}{
+		{scheme: "https://", target: "10.0.0.1", want: "https://10.0.0.1:4000/"},
+		{scheme: "https://", target: "bastion.example.com/admin", want: "https://bastion.example.com:4000/admin"},
+		{scheme: "https://", target: "2001:db8::1", want: "https://[2001:db8::1]:4000/"},
+		{scheme: "ssh://", target: "admin@bastion", want: "ssh://admin@bastion:4000/"},
+		{scheme: "https://", target: "http://bastion:8080/status?full=1#top", want: "http://bastion:4000/status?full=1#top"},
+		{scheme: "https://", target: "https://[2001:db8::1]/", want: "https://[2001:db8::1]:4000/"},
+		{scheme: "https://", target: "postgres://app@db1:{port}/app", want: "postgres://app@db1:4000/app"},
+		{scheme: "https://", target: "jdbc:postgresql://db1:{port}/app", want: "jdbc:postgresql://db1:4000/app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := buildURI(tt.scheme, tt.target, 4000)
+			if err != nil {
+				t.Fatalf("buildURI() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("buildURI() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildURIErrors(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{target: "file:///srv/site", want: "has no host, use {port}"},
+		{target: "https://bastion:%zz/", want: "invalid url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			_, err := buildURI("https://", tt.target, 4000)
+			if err == nil {
+				t.Fatalf("buildURI() error = nil, want %q", tt.want)
+			}
+
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("buildURI() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandHost(t *testing.T) {
+	tests := []struct {
+		target  string
+		address string
+		want    string
+	}{
+		{target: "", address: "10.0.0.1", want: "10.0.0.1"},
+		{target: "postgres://app@{host}:{port}/app", address: "db1.example.com", want: "postgres://app@db1.example.com:{port}/app"},
+		{target: "{host}:{port}", address: "2001:db8::1", want: "[2001:db8::1]:{port}"},
+		{target: "https://example.com/", address: "10.0.0.1", want: "https://example.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := expandHost(tt.target, &options.Host{Name: "prod", Address: tt.address})
+			if err != nil {
+				t.Fatalf("expandHost() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("expandHost() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := expandHost("{host}:{port}", nil); err == nil || !strings.Contains(err.Error(), "needs a --host") {
+		t.Errorf("expandHost() without a host error = %v, want it to ask for --host", err)
+	}
+
+	if got, err := expandHost("https://example.com/", nil); err != nil || got != "https://example.com/" {
+		t.Errorf("expandHost() without a host = %q, %v, want the target unchanged", got, err)
+	}
+}
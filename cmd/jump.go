@@ -1,13 +1,10 @@
 package cmd
 
 import (
-	"os"
-	"os/signal"
+	"context"
 	"port-jump/internal/options"
 	"port-jump/pkg/firewall"
-	"port-jump/pkg/hotp"
-	"syscall"
-	"time"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -17,78 +14,48 @@ import (
 var jumpCmd = &cobra.Command{
 	Use:   "jump",
 	Short: "Ensures a port jump remains configured.",
+	Annotations: map[string]string{
+		configAnnotation: systemConfig,
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		skip, err := cmd.Flags().GetBool("skip-cleanup")
-		if err != nil {
-			log.Error().Err(err).Msg("failed to parse skip-cleanup configuration")
-			return
+		d := daemon{
+			what:       "jumps",
+			configured: haveJumps,
+			start:      startJumps,
+			cleanup:    firewall.DeleteRules,
 		}
 
-		defer func() {
-			if skip {
-				return
-			}
-
-			if err := firewall.DeleteRules(); err != nil {
-				log.Error().Err(err).Msg("failed to cleanup firewall rules")
-			}
-		}()
+		d.run(cmd)
+	},
+}
 
-		// Channel to listen for termination signal
-		stopChan := make(chan os.Signal, 1)
-		signal.Notify(stopChan, syscall.SIGINT, syscall.SIGTERM)
+// startJumps starts a port jumping goroutine for every enabled jump.
+// the goroutines run until ctx is cancelled, which the returned WaitGroup can wait for.
+func startJumps(ctx context.Context) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
 
-		if !haveJumps() {
-			log.Error().Msg("there are no enabled jumps in the configuration file")
-			skip = true // dont do any cleanups, we didnt create anything.
-			return
+	for _, jump := range opts.Jumps {
+		if !jump.Enabled {
+			continue
 		}
 
-		// loop the configured jumps
-		for _, jump := range opts.Jumps {
-			if !jump.Enabled {
-				continue
-			}
+		wg.Add(1)
+		go func(j *options.PortJump) {
+			defer wg.Done()
 
-			go func(j *options.PortJump) {
-				jmpLog := log.With().Int("dst", j.DstPort).Bool("enabled", j.Enabled).Logger()
+			jmpLog := log.With().Str("name", j.Name).Int("dst", j.DstPort).Bool("enabled", j.Enabled).Logger()
 
-				portGen, err := hotp.NewTotp(j.SharedSecret, j.Interval)
-				if err != nil {
-					jmpLog.Error().Err(err).Msg("failed to get port generator for jump")
-					return
+			rotate(ctx, j, jmpLog, func(port int) {
+				if err := firewall.AddOrUpdateRedirect(j.Name, j.Interface, port, j.DstPort); err != nil {
+					jmpLog.Error().Err(err).Msg("failed to update nftables")
 				}
 
-				var port = 0
-
-				for {
-					newPort, err := portGen.GenerateTCPPort()
-					if err != nil {
-						jmpLog.Error().Err(err).Msg("failed to get a tcp port from portGen")
-						return
-					}
-
-					if newPort == port {
-						time.Sleep(time.Millisecond * 500)
-						continue
-					}
-
-					port = newPort
-					if err := firewall.AddOrUpdateRedirect(port, j.DstPort); err != nil {
-						jmpLog.Error().Err(err).Msg("failed to update nftables")
-					}
-
-					jmpLog.Info().Int("new-port", port).Msg("port jumped")
-				}
-
-			}(jump)
-		}
-
-		// block until we need to leave
-		<-stopChan
+				jmpLog.Info().Int("new-port", port).Msg("port jumped")
+			})
+		}(jump)
+	}
 
-		log.Info().Msg("exiting")
-	},
+	return wg
 }
 
 // haveJumps checks if there are any enabled jumps
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"port-jump/internal/options"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// errNotConfirmed is returned when a change was not confirmed
+var errNotConfirmed = errors.New("not confirmed")
+
+// addOutputFlag adds the --output flag to cmd, accepting formats
+func addOutputFlag(cmd *cobra.Command, formats ...string) {
+	if len(formats) == 0 {
+		formats = []string{outputText, outputJSON}
+	}
+
+	cmd.Flags().StringP("output", "o", formats[0], fmt.Sprintf("Output format. One of: %v", formats))
+	cmd.Flags().SetAnnotation("output", "formats", formats)
+}
+
+// outputFormat returns the validated --output format of cmd
+func outputFormat(cmd *cobra.Command) (string, error) {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return "", err
+	}
+
+	for _, format := range cmd.Flags().Lookup("output").Annotations["formats"] {
+		if output == format {
+			return output, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown output format %q", output)
+}
+
+// writeJSON writes v to stdout as indented JSON
+func writeJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+// reportError reports err in the commands output format, and returns it so
+// that the command exits with a non-zero status.
+func reportError(cmd *cobra.Command, err error) error {
+	if output, _ := cmd.Flags().GetString("output"); output == outputJSON {
+		writeJSON(struct {
+			Error string `json:"error"`
+		}{err.Error()})
+
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+	return err
+}
+
+// interactive reports if forms can be shown, i.e. stdin and stdout are terminals
+func interactive() bool {
+	isTerminal := func(fd uintptr) bool {
+		return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+	}
+
+	return isTerminal(os.Stdin.Fd()) && isTerminal(os.Stdout.Fd())
+}
+
+// confirmChange asks for confirmation of a change, unless --yes was given.
+// It is an error to need confirmation without a terminal.
+func confirmChange(cmd *cobra.Command, title string) error {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return nil
+	}
+
+	if !interactive() {
+		return errors.New("not running in a terminal, use --yes to confirm the change")
+	}
+
+	var confirm bool
+	err := huh.NewConfirm().
+		Title(title).
+		Affirmative("Yes!").
+		Negative("No.").
+		Value(&confirm).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	if !confirm {
+		return errNotConfirmed
+	}
+
+	return nil
+}
+
+// notChanged reports that a change was not made, as message or, for JSON
+// output, as a result without changes
+func notChanged(output string, message string) error {
+	if output == outputJSON {
+		return writeJSON(struct {
+			Changed bool `json:"changed"`
+		}{false})
+	}
+
+	fmt.Println(message)
+
+	return nil
+}
+
+// jumpResult is the JSON representation of a jump in command output
+type jumpResult struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	Interface    string   `json:"interface,omitempty"`
+	Enabled      bool     `json:"enabled"`
+	DstPort      int      `json:"dstport"`
+	Interval     int64    `json:"interval"`
+	SharedSecret string   `json:"sharedsecret,omitempty"`
+}
+
+// newJumpResult returns the JSON representation of jump. The shared
+// secret is only included if withSecret is set.
+func newJumpResult(jump *options.PortJump, withSecret bool) jumpResult {
+	r := jumpResult{
+		Name:        jump.Name,
+		Description: jump.Description,
+		Tags:        jump.Tags,
+		Interface:   jump.Interface,
+		Enabled:     jump.Enabled,
+		DstPort:     jump.DstPort,
+		Interval:    jump.Interval,
+	}
+
+	if withSecret {
+		r.SharedSecret = jump.SharedSecret
+	}
+
+	return r
+}
+
+// newJumpResults returns the JSON representation of jumps, without secrets
+func newJumpResults(jumps []*options.PortJump) []jumpResult {
+	results := make([]jumpResult, 0, len(jumps))
+	for _, jump := range jumps {
+		results = append(results, newJumpResult(jump, false))
+	}
+
+	return results
+}
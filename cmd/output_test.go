@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"port-jump/internal/options"
+
+	"github.com/spf13/cobra"
+)
+
+// captureStdout returns what fn writes to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+
+	fn()
+	w.Close()
+
+	return <-done
+}
+
+// newOutputTestCmd returns a command with the output flag set to output
+func newOutputTestCmd(t *testing.T, output string, formats ...string) *cobra.Command {
+	t.Helper()
+
+	cmd := &cobra.Command{}
+	addOutputFlag(cmd, formats...)
+	cmd.Flags().Bool("yes", false, "")
+
+	if err := cmd.Flags().Set("output", output); err != nil {
+		t.Fatal(err)
+	}
+
+	return cmd
+}
+
+func TestOutputFormat(t *testing.T) {
+	tests := []struct {
+		output  string
+		formats []string
+		wantErr bool
+	}{
+		{output: outputText},
+		{output: outputJSON},
+		{output: "yaml", wantErr: true},
+		{output: outputShell, formats: []string{outputText, outputJSON, outputEnv, outputShell}},
+		{output: outputShell, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			got, err := outputFormat(newOutputTestCmd(t, tt.output, tt.formats...))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "unknown output format") {
+					t.Errorf("outputFormat() error = %v, want an unknown output format error", err)
+				}
+				return
+			}
+
+			if err != nil || got != tt.output {
+				t.Errorf("outputFormat() = %q, %v, want %q", got, err, tt.output)
+			}
+		})
+	}
+}
+
+func TestReportErrorJSON(t *testing.T) {
+	cmd := newOutputTestCmd(t, outputJSON)
+
+	var returned error
+	out := captureStdout(t, func() {
+		returned = reportError(cmd, errors.New("no jump matching port 80 found"))
+	})
+
+	if returned == nil {
+		t.Error("reportError() = nil, want the reported error")
+	}
+
+	var result struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("reportError() output %q is not JSON: %v", out, err)
+	}
+
+	if result.Error != "no jump matching port 80 found" {
+		t.Errorf("reportError() error = %q, want the error as is", result.Error)
+	}
+}
+
+func TestNotChanged(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{output: outputText, want: "Not adding a new jump.\n"},
+		{output: outputJSON, want: "{\n  \"changed\": false\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			got := captureStdout(t, func() {
+				if err := notChanged(tt.output, "Not adding a new jump."); err != nil {
+					t.Errorf("notChanged() error = %v", err)
+				}
+			})
+
+			if got != tt.want {
+				t.Errorf("notChanged() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmChange(t *testing.T) {
+	if interactive() {
+		t.Skip("running in a terminal, confirmChange would show a form")
+	}
+
+	cmd := newOutputTestCmd(t, outputText)
+
+	// without a terminal, only --yes confirms
+	if err := confirmChange(cmd, "Sure?"); err == nil || !strings.Contains(err.Error(), "use --yes") {
+		t.Errorf("confirmChange() without a terminal error = %v, want it to ask for --yes", err)
+	}
+
+	cmd.Flags().Set("yes", "true")
+	if err := confirmChange(cmd, "Sure?"); err != nil {
+		t.Errorf("confirmChange() with --yes error = %v", err)
+	}
+}
+
+func TestNewJumpResult(t *testing.T) {
+	jump := &options.PortJump{Name: "ssh", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"}
+
+	if r := newJumpResult(jump, false); r.SharedSecret != "" {
+		t.Errorf("newJumpResult() without secret = %+v, want no shared secret", r)
+	}
+
+	if r := newJumpResult(jump, true); r.SharedSecret != "JBSWY3DPEHPK3PXP" || r.Name != "ssh" || r.DstPort != 22 {
+		t.Errorf("newJumpResult() with secret = %+v, want the jump and its secret", r)
+	}
+
+	// an empty list is encoded as [], not null
+	if data, _ := json.Marshal(newJumpResults(nil)); string(data) != "[]" {
+		t.Errorf("newJumpResults(nil) = %s, want []", data)
+	}
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/signal"
+	"port-jump/internal/client"
+	"port-jump/internal/options"
+	"port-jump/internal/proxy"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// proxyCmd represents the proxy command
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Run a local SOCKS5 and HTTP CONNECT proxy for jumped services",
+	Long: `Run a local SOCKS5 and HTTP CONNECT proxy for jumped services.
+
+When a requested host matches a host entry by name, alias or address, and
+the requested port is the destination port of one of its enabled jumps, the
+proxy connects to the jump's current port instead. Every other destination is
+passed through unchanged.
+
+Point browsers and other tools with proxy settings at it, to reach every
+jumped service without a forwarder per service. The proxy does not
+authenticate clients, so only listen on addresses you trust.`,
+	Example: `  port-jump proxy
+  port-jump proxy --listen 127.0.0.1:8118
+  curl --proxy socks5h://127.0.0.1:1080 https://prod-bastion/`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, _ := cmd.Flags().GetString("listen")
+		margin, _ := cmd.Flags().GetDuration("margin")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		listener, err := net.Listen("tcp", listen)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		server := &proxy.Server{
+			Resolve: func(host string, port int) (string, []int, error) {
+				return resolveProxyDestination(host, port, margin)
+			},
+			Timeout: timeout,
+			Report: func(destination string, address string, port int, err error) {
+				if err != nil {
+					log.Warn().Err(err).Str("destination", destination).Int("port", port).Msg("failed to connect")
+					return
+				}
+
+				log.Debug().Str("destination", destination).Str("address", address).Int("port", port).Msg("connected")
+			},
+		}
+
+		log.Info().Str("listen", listener.Addr().String()).Msg("proxying")
+
+		if err := server.Serve(ctx, listener); err != nil {
+			return reportError(cmd, err)
+		}
+
+		log.Info().Msg("exiting")
+
+		return nil
+	},
+}
+
+// resolveProxyDestination returns where to connect to for a proxied host and
+// port. Destinations of enabled host entry jumps are rewritten to the
+// current port, like the rewrite command does.
+func resolveProxyDestination(host string, port int, margin time.Duration) (string, []int, error) {
+	// SOCKS5 and CONNECT requests for IPv6 addresses may be bracketed
+	host = strings.Trim(host, "[]")
+
+	entry := opts.Host(host)
+	if entry == nil {
+		entry = opts.HostByAddress(host)
+	}
+
+	if entry == nil {
+		return host, []int{port}, nil
+	}
+
+	var jumps []*options.PortJump
+	for _, jump := range entry.Select(options.Selector{Port: port}) {
+		if jump.Enabled {
+			jumps = append(jumps, jump)
+		}
+	}
+
+	switch len(jumps) {
+	case 0:
+		return host, []int{port}, nil
+	case 1:
+	default:
+		return "", nil, fmt.Errorf("more than one jump of host %s uses port %d", entry.Name, port)
+	}
+
+	ports, err := client.Candidates(jumps[0], time.Now(), margin)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return entry.Address, ports, nil
+}
+
+func init() {
+	rootCmd.AddCommand(proxyCmd)
+
+	proxyCmd.Flags().String("listen", "127.0.0.1:1080", "Local address to listen on")
+	addMarginFlag(proxyCmd.Flags())
+	proxyCmd.Flags().Duration("timeout", 10*time.Second, "Timeout connecting to destinations")
+}
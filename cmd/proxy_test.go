@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+
+	"port-jump/internal/options"
+)
+
+func TestResolveProxyDestination(t *testing.T) {
+	saved := opts
+	defer func() { opts = saved }()
+
+	opts = &options.Options{Hosts: []*options.Host{{
+		Name:    "prod",
+		Address: "10.0.0.1",
+		Jumps: []*options.PortJump{
+			{Name: "ssh", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"},
+			{Name: "web", DstPort: 443, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"},
+		},
+	}}}
+
+	address, ports, err := resolveProxyDestination("prod", 22, 0)
+	if err != nil {
+		t.Fatalf("resolveProxyDestination() error = %v", err)
+	}
+	if address != "10.0.0.1" || len(ports) != 1 || ports[0] == 22 {
+		t.Errorf("resolveProxyDestination(prod, 22) = %s, %v, want the current port of 10.0.0.1", address, ports)
+	}
+
+	// disabled jumps are passed through, as the rewrite command does
+	address, ports, err = resolveProxyDestination("prod", 443, 0)
+	if err != nil {
+		t.Fatalf("resolveProxyDestination() error = %v", err)
+	}
+	if address != "prod" || len(ports) != 1 || ports[0] != 443 {
+		t.Errorf("resolveProxyDestination(prod, 443) = %s, %v, want prod:443 unchanged", address, ports)
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"port-jump/internal/options"
+	"port-jump/pkg/firewall"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// rewriteCmd represents the rewrite command
+var rewriteCmd = &cobra.Command{
+	Use:   "rewrite",
+	Short: "Transparently rewrite connections to host entries to their jumped port.",
+	Long: `Transparently rewrite connections to host entries to their jumped port.
+
+This is the client side counterpart of the jump command. For every jump of
+every host entry, an nftables output rule rewrites outgoing connections to the
+host's address and the jump's destination port to the current jumped port,
+and is updated every time the port changes. Plain 'ssh bastion' or any other
+tool then works without a wrapper, forwarder or proxy.
+
+Host addresses are resolved to IPv4 addresses every time the port changes.
+Like the jump command, this needs permission to manage nftables, and the
+configuration is reloaded on SIGHUP.`,
+	Annotations: map[string]string{
+		configAnnotation: systemConfig,
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		d := daemon{
+			what:       "host entry jumps",
+			configured: haveHostJumps,
+			start:      startRewrites,
+			cleanup:    firewall.DeleteDNATRules,
+		}
+
+		d.run(cmd)
+	},
+}
+
+// startRewrites starts a goroutine rewriting connections for every enabled
+// jump of every host entry. the goroutines run until ctx is cancelled, which
+// the returned WaitGroup can wait for.
+func startRewrites(ctx context.Context) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+
+	for _, host := range opts.Hosts {
+		for _, jump := range host.Jumps {
+			if !jump.Enabled {
+				continue
+			}
+
+			wg.Add(1)
+			go func(h *options.Host, j *options.PortJump) {
+				defer wg.Done()
+
+				jmpLog := log.With().Str("host", h.Name).Str("name", j.Name).Int("dst", j.DstPort).Logger()
+
+				rotate(ctx, j, jmpLog, func(port int) {
+					// on errors, try again once the port changes
+					addrs, err := hostIPv4(ctx, h.Address)
+					if err != nil {
+						jmpLog.Error().Err(err).Str("address", h.Address).Msg("failed to resolve host address")
+					} else if err := firewall.AddOrUpdateDNAT(h.Name+"/"+j.Name, addrs, j.DstPort, port); err != nil {
+						jmpLog.Error().Err(err).Msg("failed to update nftables")
+					} else {
+						jmpLog.Info().Int("new-port", port).Msg("port rewritten")
+					}
+				})
+			}(host, jump)
+		}
+	}
+
+	return wg
+}
+
+// hostIPv4 resolves address to its IPv4 addresses
+func hostIPv4(ctx context.Context, address string) ([]net.IP, error) {
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return ips, nil
+}
+
+// haveHostJumps checks if there are any enabled jumps in host entries
+func haveHostJumps() bool {
+	var enabled bool
+	for _, host := range opts.Hosts {
+		for _, jump := range host.Jumps {
+			if jump.Enabled {
+				enabled = true
+			}
+		}
+	}
+
+	return enabled
+}
+
+func init() {
+	rootCmd.AddCommand(rewriteCmd)
+
+	rewriteCmd.PersistentFlags().BoolP("skip-cleanup", "", false, "Do not cleanup the rewrite firewall table on exit")
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"port-jump/internal/options"
+)
+
+func TestHaveHostJumps(t *testing.T) {
+	saved := opts
+	defer func() { opts = saved }()
+
+	tests := []struct {
+		name string
+		opts *options.Options
+		want bool
+	}{
+		{
+			name: "server jumps only",
+			opts: &options.Options{Jumps: []*options.PortJump{{Name: "ssh", Enabled: true, DstPort: 22}}},
+		},
+		{
+			name: "disabled host jumps",
+			opts: &options.Options{Hosts: []*options.Host{{Name: "prod", Jumps: []*options.PortJump{{Name: "ssh", DstPort: 22}}}}},
+		},
+		{
+			name: "enabled host jump",
+			opts: &options.Options{Hosts: []*options.Host{
+				{Name: "prod", Jumps: []*options.PortJump{{Name: "ssh", DstPort: 22}}},
+				{Name: "dev", Jumps: []*options.PortJump{{Name: "ssh", Enabled: true, DstPort: 22}}},
+			}},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts = tt.opts
+
+			if got := haveHostJumps(); got != tt.want {
+				t.Errorf("haveHostJumps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostIPv4(t *testing.T) {
+	ips, err := hostIPv4(context.Background(), "127.0.0.1")
+	if err != nil {
+		t.Fatalf("hostIPv4() error = %v", err)
+	}
+
+	if len(ips) != 1 || !ips[0].Equal([]byte{127, 0, 0, 1}) {
+		t.Errorf("hostIPv4() = %v, want 127.0.0.1", ips)
+	}
+
+	if _, err := hostIPv4(context.Background(), "2001:db8::1"); err == nil {
+		t.Error("hostIPv4() of an IPv6 address error = nil, want no IPv4 addresses")
+	}
+}
@@ -13,38 +13,87 @@ import (
 
 var opts = options.NewOptions()
 
+const (
+	// configAnnotation is a command annotation selecting the default config file
+	configAnnotation = "default-config"
+	// systemConfig selects the system-wide config file as the default
+	systemConfig = "system"
+)
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "port-jump",
 	Short: "A proof-of-concept 'port-jump' tool. Change the port something listens on, over time.",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		if err := rootCmdValidator(cmd); err != nil {
+		if err := loadConfig(cmd); err != nil {
+			// commands that silence errors report their own, which cobra
+			// does not do for errors returned here
+			if cmd.SilenceErrors {
+				return reportError(cmd, err)
+			}
+
 			return err
 		}
 
-		if err := opts.Load(); err != nil {
-			return err
+		return nil
+	},
+}
+
+// isConfigCommand reports if cmd is the config command or one of its subcommands
+func isConfigCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == configCmd {
+			return true
 		}
+	}
 
-		log.Setup(opts.LogDebug)
+	return false
+}
 
-		if len(opts.Jumps) == 0 {
-			zlog.Warn().Msg("no configurations found. generating a disabled ssh example for you. check out the config file for details")
-			s, err := secrets.GenerateTOTPSecret(16)
-			if err != nil {
-				return err
-			}
-			jmp, err := options.NewPortJump(22, s, int64(30), false)
-			if err != nil {
-				return err
-			}
+// loadConfig sets up logging and loads the configuration for cmd. If there is
+// no configuration yet, a disabled example jump is written, unless cmd is a
+// config command.
+func loadConfig(cmd *cobra.Command) error {
+	if err := rootCmdValidator(cmd); err != nil {
+		return err
+	}
+
+	if err := opts.Load(); err != nil {
+		return err
+	}
+
+	// jumps with unresolvable secrets fail on use, the others keep working
+	for _, err := range opts.ResolveErrors() {
+		zlog.Warn().Err(err).Msg("jump cannot be used until its shared secret can be resolved")
+	}
+
+	if err := opts.MigrateErr(); err != nil {
+		zlog.Warn().Err(err).Strs("files", opts.Outdated()).Msg("configuration uses an older schema version and could not be migrated, using it as is")
+	}
+
+	for _, backup := range opts.Backups() {
+		zlog.Warn().Str("backup", backup).Msg("configuration migrated to a newer schema version, a backup of the original was written")
+	}
 
-			opts.Jumps = append(opts.Jumps, jmp)
-			opts.Save()
+	// there is nothing to write an example to when configured from the
+	// environment, and config commands are used to set up jumps, where an
+	// example would only get in the way
+	if len(opts.Jumps) == 0 && len(opts.Hosts) == 0 && !opts.FromEnvironment() && !isConfigCommand(cmd) {
+		zlog.Warn().Msg("no configurations found. generating a disabled ssh example for you. check out the config file for details")
+		s, err := secrets.GenerateTOTPSecret(16)
+		if err != nil {
+			return err
+		}
+		jmp, err := options.NewPortJump("ssh", 22, s, int64(30), false)
+		if err != nil {
+			return err
 		}
 
-		return nil
-	},
+		opts.Jumps = append(opts.Jumps, jmp)
+		opts.Save()
+	}
+
+	return nil
 }
 
 func Execute() {
@@ -62,10 +111,50 @@ func rootCmdValidator(cmd *cobra.Command) error {
 	}
 
 	opts.LogDebug = debug
+	log.Setup(opts.LogDebug)
+
+	config, err := cmd.Flags().GetString("config")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case config != "":
+		opts.SetConfigPath(config)
+	case os.Getenv(options.ConfigEnv) != "":
+		// the options package reads the path from the environment
+	case options.EnvConfigured():
+		opts.UseEnvironment()
+	case cmd.Annotations[configAnnotation] == systemConfig:
+		opts.SetConfigPath(systemConfigPath())
+	}
 
 	return nil
 }
 
+// systemConfigPath returns the system-wide configuration file path. If it does
+// not exist yet, but a per-user configuration does, the per-user file is used
+// so that existing daemon setups keep working.
+func systemConfigPath() string {
+	if _, err := os.Stat(options.SystemConfigPath); err == nil {
+		return options.SystemConfigPath
+	}
+
+	userPath, err := options.DefaultConfigPath()
+	if err != nil {
+		return options.SystemConfigPath
+	}
+
+	if _, err := os.Stat(userPath); err == nil {
+		zlog.Warn().Str("path", userPath).Str("system-path", options.SystemConfigPath).
+			Msg("using the per-user configuration file. consider moving it to the system path")
+		return userPath
+	}
+
+	return options.SystemConfigPath
+}
+
 func init() {
 	rootCmd.PersistentFlags().BoolP("debug", "D", false, "debug")
+	rootCmd.PersistentFlags().String("config", "", "Configuration file to use (env: "+options.ConfigEnv+")")
 }
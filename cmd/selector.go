@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"port-jump/internal/options"
+
+	"github.com/spf13/cobra"
+)
+
+// addSelectorFlags adds the flags used to select jumps to cmd
+func addSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("name", "n", "", "Name of the jump to use")
+	cmd.Flags().StringP("tag", "t", "", "Tag of the jump to use")
+	cmd.Flags().IntP("port", "p", 0, "Destination port to use from configuration file")
+}
+
+// jumpSelector returns the jump selector configured with flags, and an optional name argument
+func jumpSelector(cmd *cobra.Command, args []string) options.Selector {
+	name, _ := cmd.Flags().GetString("name")
+	tag, _ := cmd.Flags().GetString("tag")
+	port, _ := cmd.Flags().GetInt("port")
+
+	if name == "" && len(args) > 0 {
+		name = args[0]
+	}
+
+	return options.Selector{Name: name, Tag: tag, Port: port}
+}
+
+// addHostFlag adds the flag used to select jumps of a client host entry to cmd
+func addHostFlag(cmd *cobra.Command) {
+	cmd.Flags().String("host", "", "Name or alias of the host entry to select the jump from")
+}
+
+// selectJump returns the single jump selected with flags and args. With
+// --host, the jump is selected from that host entry, which is returned too.
+func selectJump(cmd *cobra.Command, args []string) (*options.PortJump, *options.Host, error) {
+	selector := jumpSelector(cmd, args)
+
+	name, _ := cmd.Flags().GetString("host")
+	if name == "" {
+		jump, err := opts.SelectOne(selector)
+		return jump, nil, err
+	}
+
+	host := opts.Host(name)
+	if host == nil {
+		return nil, nil, fmt.Errorf("no host named %s found", name)
+	}
+
+	jump, err := host.SelectOne(selector)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return jump, host, nil
+}
+
+// resolveHost resolves a host name, alias or address given on the command
+// line to the jump to use and the address to connect to. Host entries are
+// looked up first. Without a matching entry, the jump is selected from the
+// configured jumps, and name is used as the address.
+func resolveHost(name string, selector options.Selector) (*options.PortJump, *options.Host, string, error) {
+	host := opts.Host(name)
+	if host == nil {
+		host = opts.HostByAddress(name)
+	}
+
+	if host == nil {
+		jump, err := opts.SelectOne(selector)
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		return jump, nil, name, nil
+	}
+
+	jump, err := host.SelectOne(selector)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return jump, host, host.Address, nil
+}
@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"port-jump/internal/client"
+	"port-jump/internal/options"
+	"port-jump/internal/sshconfig"
+	"strconv"
+	"strings"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// sshOptionsWithArgs are the ssh options that take an argument
+const sshOptionsWithArgs = "BbcDEeFIiJLlmOoPpQRSWw"
+
+// sshCmd represents the ssh command
+var sshCmd = &cobra.Command{
+	Use:   "ssh [flags] [ssh arguments] destination [command]",
+	Short: "Run ssh with the current port of a jump",
+	Long: `Run ssh with the current port of a jump.
+
+The destination is resolved through host entries by name, alias or address,
+and the jump for its ssh port is used. Without a matching host entry, the
+jump is selected from the configured jumps by port. Every ssh argument is
+passed on as is, while port-jump flags have to come first.
+
+Before ssh is started, the port is probed. If the connection is refused close
+to a window boundary, the port of the previous or next window is used
+instead, so that small clock differences do not break the connection. To
+avoid a new known_hosts entry for every port, HostKeyAlias is set to the host
+entry's name, or the destination host, as [host]:port for jumps to ports
+other than 22. The destination is passed on as given,
+with HostName set to the host entry's address, so that Host blocks for it in
+ssh_config keep applying.`,
+	Example: `  port-jump ssh user@prod-bastion
+  port-jump ssh -i ~/.ssh/id_ed25519 -L 8080:localhost:80 admin@10.211.55.6
+  port-jump ssh --jump ssh-admin --dry-run prod-bastion`,
+	// ssh flags are passed on to ssh, port-jump flags are parsed in RunE
+	DisableFlagParsing: true,
+	SilenceUsage:       true,
+	SilenceErrors:      true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		own, rest := splitOwnFlags(cmd, args)
+		if err := cmd.Flags().Parse(own); err != nil {
+			return reportError(cmd, err)
+		}
+
+		if help, _ := cmd.Flags().GetBool("help"); help {
+			return cmd.Help()
+		}
+
+		if err := loadConfig(cmd); err != nil {
+			return reportError(cmd, err)
+		}
+
+		ssh, err := parseSSHArgs(rest)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		user, hostname, port, err := splitDestination(ssh.args[ssh.destination])
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		if ssh.port != 0 {
+			port = ssh.port
+		}
+
+		name, _ := cmd.Flags().GetString("jump")
+		selector := options.Selector{Name: name}
+		if name == "" {
+			selector.Port = port
+		}
+
+		jump, host, address, err := resolveHost(hostname, selector)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		port, err = probePort(cmd, jump, address)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		// match the known_hosts entries of generated ssh_config Host blocks
+		alias := hostname
+		if host != nil {
+			alias = host.Name
+		}
+		alias = sshconfig.HostKeyAlias(alias, jump.DstPort)
+
+		// keep the destination as given, so that Host blocks for it in
+		// ssh_config still match, and only point ssh at the address
+		destination := ssh.args[ssh.destination]
+		if strings.HasPrefix(destination, "ssh://") {
+			// the port of ssh:// destinations would take precedence over -p
+			destination = hostname
+			if user != "" {
+				destination = user + "@" + hostname
+			}
+		}
+
+		sshArgs := []string{"-p", strconv.Itoa(port)}
+		if address != hostname {
+			sshArgs = append(sshArgs, "-o", "HostName="+address)
+		}
+		if !ssh.hostKeyAlias {
+			sshArgs = append(sshArgs, "-o", "HostKeyAlias="+alias)
+		}
+		sshArgs = append(sshArgs, ssh.without(destination)...)
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			fmt.Println("ssh " + strings.Join(sshArgs, " "))
+			return nil
+		}
+
+		os.Exit(runCommand("ssh", sshArgs))
+		return nil
+	},
+}
+
+// splitOwnFlags splits args into the leading port-jump flags and the
+// arguments after them. Only long flags are accepted, as ssh uses every
+// short flag. A -- ends the port-jump flags explicitly.
+func splitOwnFlags(cmd *cobra.Command, args []string) ([]string, []string) {
+	// with flag parsing disabled, inherited flags are not merged into cmd.Flags() yet
+	cmd.InheritedFlags()
+
+	var own []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return own, args[i+1:]
+		}
+
+		if !strings.HasPrefix(arg, "--") {
+			return own, args[i:]
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			return own, args[i:]
+		}
+
+		own = append(own, arg)
+		if !hasValue && flag.NoOptDefVal == "" && i+1 < len(args) {
+			i++
+			own = append(own, args[i])
+		}
+	}
+
+	return own, nil
+}
+
+// sshArgs is a parsed ssh command line
+type sshArgs struct {
+	args []string
+	// destination is the index of the destination in args
+	destination int
+	// port is the port set with -p, if any
+	port int
+	// portArgs are the indexes in args that set the port, to be replaced
+	portArgs []int
+	// hostKeyAlias is set if a HostKeyAlias option is given
+	hostKeyAlias bool
+}
+
+// parseSSHArgs finds the destination and port in an ssh command line
+func parseSSHArgs(args []string) (*sshArgs, error) {
+	ssh := &sshArgs{args: append([]string{}, args...), destination: -1}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			ssh.destination = i
+			return ssh, nil
+		}
+
+		// options can be grouped, the first one taking an argument ends the group
+		for j := 1; j < len(arg); j++ {
+			option := arg[j]
+			if !strings.ContainsRune(sshOptionsWithArgs, rune(option)) {
+				continue
+			}
+
+			indexes := []int{i}
+			value := arg[j+1:]
+			if value == "" {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("ssh option -%c needs an argument", option)
+				}
+				i++
+				indexes = append(indexes, i)
+				value = args[i]
+			}
+
+			switch option {
+			case 'p':
+				port, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid ssh port %q", value)
+				}
+				ssh.port = port
+
+				// keep the options grouped before -p, i.e. -v of -vp22
+				if j > 1 {
+					ssh.args[i-len(indexes)+1] = arg[:j]
+					indexes = indexes[1:]
+				}
+				ssh.portArgs = append(ssh.portArgs, indexes...)
+			case 'o':
+				key, _, _ := strings.Cut(value, "=")
+				if strings.EqualFold(strings.TrimSpace(key), "HostKeyAlias") {
+					ssh.hostKeyAlias = true
+				}
+			}
+
+			break
+		}
+	}
+
+	return nil, errors.New("no ssh destination given")
+}
+
+// without returns the ssh arguments without the port options, and the
+// destination replaced
+func (s *sshArgs) without(destination string) []string {
+	args := make([]string, 0, len(s.args))
+
+outer:
+	for i, arg := range s.args {
+		for _, index := range s.portArgs {
+			if i == index {
+				continue outer
+			}
+		}
+
+		if i == s.destination {
+			arg = destination
+		}
+
+		args = append(args, arg)
+	}
+
+	return args
+}
+
+// splitDestination splits an ssh destination, [user@]host or
+// ssh://[user@]host[:port], into its parts. The port defaults to 22.
+func splitDestination(destination string) (string, string, int, error) {
+	if !strings.HasPrefix(destination, "ssh://") {
+		user, host, ok := strings.Cut(destination, "@")
+		if !ok {
+			return "", destination, 22, nil
+		}
+
+		return user, host, 22, nil
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid ssh destination: %v", err)
+	}
+
+	port := 22
+	if u.Port() != "" {
+		if port, err = strconv.Atoi(u.Port()); err != nil {
+			return "", "", 0, fmt.Errorf("invalid ssh destination port %q", u.Port())
+		}
+	}
+
+	return u.User.Username(), u.Hostname(), port, nil
+}
+
+// probePort returns the port to connect to for jump on address. Near a window
+// boundary, the adjacent window's port is used if the current one is refused.
+func probePort(cmd *cobra.Command, jump *options.PortJump, address string) (int, error) {
+	margin, _ := cmd.Flags().GetDuration("margin")
+	timeout, _ := cmd.Flags().GetDuration("probe-timeout")
+
+	ports, err := client.Candidates(jump, time.Now(), margin)
+	if err != nil {
+		return 0, err
+	}
+
+	if timeout <= 0 {
+		return ports[0], nil
+	}
+
+	conn, port, err := client.Dial(context.Background(), address, ports, timeout)
+	if err != nil {
+		// let the command report the error, it may reach the host through a proxy
+		zlog.Debug().Err(err).Int("port", port).Msg("failed to probe port")
+		return port, nil
+	}
+	conn.Close()
+
+	if port != ports[0] {
+		zlog.Debug().Int("port", port).Int("current", ports[0]).Msg("current port refused, using the adjacent window's port")
+	}
+
+	return port, nil
+}
+
+// runCommand runs name with args attached to the terminal, and returns its
+// exit code. env is added to the environment of the command. Interrupts are
+// left for the command to handle.
+func runCommand(name string, args []string, env ...string) int {
+	c := exec.Command(name, args...)
+	if len(env) > 0 {
+		c.Env = append(os.Environ(), env...)
+	}
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+
+		fmt.Fprintf(os.Stderr, "Error: failed to run %s: %v\n", name, err)
+		return 1
+	}
+
+	return 0
+}
+
+// addProbeFlags adds the flags that control probing for the right port to cmd
+func addProbeFlags(flags *pflag.FlagSet) {
+	addMarginFlag(flags)
+	flags.Duration("probe-timeout", 2*time.Second, "Timeout probing the port before connecting. 0 disables probing")
+}
+
+// addMarginFlag adds the flag for how close to a window boundary the
+// adjacent window's port is tried too
+func addMarginFlag(flags *pflag.FlagSet) {
+	flags.Duration("margin", client.DefaultMargin, "Also try the adjacent window's port this close to a window boundary")
+}
+
+func init() {
+	rootCmd.AddCommand(sshCmd)
+
+	sshCmd.Flags().String("jump", "", "Name of the jump to use, instead of the jump for the ssh port")
+	sshCmd.Flags().Bool("dry-run", false, "Print the ssh command instead of running it")
+	addProbeFlags(sshCmd.Flags())
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"port-jump/internal/options"
+	"port-jump/internal/sshconfig"
+
+	"github.com/spf13/cobra"
+)
+
+// sshConfigCmd represents the ssh-config command
+var sshConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "Generate ssh_config Host blocks for host entries",
+	Long: `Generate ssh_config Host blocks for the jumps of every host entry.
+
+Each block connects through 'port-jump connect' as its ProxyCommand, and sets
+HostKeyAlias, so that the rotating port never adds known_hosts entries or
+causes host key warnings. A host's jump to port 22 can be reached as the
+host's name and aliases, and every jump as <host>-<jump>.
+
+The blocks are printed, or written to a managed section of ~/.ssh/config with
+--write. Everything outside the managed section is left untouched.`,
+	Example: `  port-jump ssh-config
+  port-jump ssh-config --write
+  port-jump ssh-config --ports --write --file ~/.ssh/port-jump.conf`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(opts.Hosts) == 0 {
+			return reportError(cmd, errors.New("no host entries configured, add hosts to generate ssh configuration for"))
+		}
+
+		command, _ := cmd.Flags().GetString("command")
+		if command == "" {
+			executable, err := os.Executable()
+			if err != nil {
+				return reportError(cmd, fmt.Errorf("failed to find the port-jump executable, use --command: %v", err))
+			}
+
+			configPath, err := opts.ConfigPath()
+			if err != nil {
+				return reportError(cmd, err)
+			}
+
+			if configPath, err = filepath.Abs(configPath); err != nil {
+				return reportError(cmd, err)
+			}
+
+			command = sshconfig.Command(executable, configPath)
+		}
+
+		ports, _ := cmd.Flags().GetBool("ports")
+
+		section, err := sshconfig.Generate(opts.Hosts, sshconfig.Config{
+			Command: command,
+			Ports:   ports,
+			Port: func(jump *options.PortJump) (int, error) {
+				totp, err := jump.Totp()
+				if err != nil {
+					return 0, err
+				}
+
+				return totp.GenerateTCPPort()
+			},
+		})
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		if write, _ := cmd.Flags().GetBool("write"); !write {
+			fmt.Print(section)
+			return nil
+		}
+
+		file, _ := cmd.Flags().GetString("file")
+		if file == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return reportError(cmd, fmt.Errorf("failed to get home directory: %v", err))
+			}
+
+			file = filepath.Join(home, ".ssh", "config")
+		}
+
+		if err := sshconfig.Update(file, section); err != nil {
+			return reportError(cmd, fmt.Errorf("failed to update ssh configuration: %v", err))
+		}
+
+		fmt.Printf("Updated the port-jump section of %s.\n", file)
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sshConfigCmd)
+
+	sshConfigCmd.Flags().Bool("write", false, "Write the Host blocks to the managed section of the ssh configuration file")
+	sshConfigCmd.Flags().String("file", "", "ssh configuration file to write to. Defaults to ~/.ssh/config")
+	sshConfigCmd.Flags().Bool("ports", false, "Write the current port instead of a ProxyCommand. Has to be refreshed every interval")
+	sshConfigCmd.Flags().String("command", "", "port-jump command to use in ProxyCommand. Defaults to this executable")
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSSHArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		destination  string
+		port         int
+		hostKeyAlias bool
+		without      []string
+	}{
+		{
+			name:        "destination only",
+			args:        []string{"bastion"},
+			destination: "bastion",
+			without:     []string{"127.0.0.1"},
+		},
+		{
+			name:        "separate port",
+			args:        []string{"-v", "-p", "2222", "admin@bastion", "uptime"},
+			destination: "admin@bastion",
+			port:        2222,
+			without:     []string{"-v", "127.0.0.1", "uptime"},
+		},
+		{
+			name:        "grouped port",
+			args:        []string{"-vp22", "bastion"},
+			destination: "bastion",
+			port:        22,
+			without:     []string{"-v", "127.0.0.1"},
+		},
+		{
+			name:        "grouped port with separate value",
+			args:        []string{"-vAp", "22", "bastion"},
+			destination: "bastion",
+			port:        22,
+			without:     []string{"-vA", "127.0.0.1"},
+		},
+		{
+			name:        "option values that look like options",
+			args:        []string{"-i", "-key", "-L8080:localhost:80", "bastion", "-p"},
+			destination: "bastion",
+			without:     []string{"-i", "-key", "-L8080:localhost:80", "127.0.0.1", "-p"},
+		},
+		{
+			name:         "host key alias",
+			args:         []string{"-o", "hostkeyalias = bastion", "bastion"},
+			destination:  "bastion",
+			hostKeyAlias: true,
+			without:      []string{"-o", "hostkeyalias = bastion", "127.0.0.1"},
+		},
+		{
+			name:        "other options",
+			args:        []string{"-oStrictHostKeyChecking=no", "bastion"},
+			destination: "bastion",
+			without:     []string{"-oStrictHostKeyChecking=no", "127.0.0.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssh, err := parseSSHArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseSSHArgs() error = %v", err)
+			}
+
+			if got := ssh.args[ssh.destination]; got != tt.destination {
+				t.Errorf("destination = %q, want %q", got, tt.destination)
+			}
+
+			if ssh.port != tt.port {
+				t.Errorf("port = %d, want %d", ssh.port, tt.port)
+			}
+
+			if ssh.hostKeyAlias != tt.hostKeyAlias {
+				t.Errorf("hostKeyAlias = %v, want %v", ssh.hostKeyAlias, tt.hostKeyAlias)
+			}
+
+			if got := ssh.without("127.0.0.1"); !reflect.DeepEqual(got, tt.without) {
+				t.Errorf("without() = %q, want %q", got, tt.without)
+			}
+		})
+	}
+}
+
+func TestParseSSHArgsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "no arguments", args: nil, want: "no ssh destination given"},
+		{name: "no destination", args: []string{"-v", "-p", "22"}, want: "no ssh destination given"},
+		{name: "missing value", args: []string{"-p"}, want: "ssh option -p needs an argument"},
+		{name: "invalid port", args: []string{"-p", "ssh", "bastion"}, want: `invalid ssh port "ssh"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSSHArgs(tt.args)
+			if err == nil {
+				t.Fatalf("parseSSHArgs() error = nil, want %q", tt.want)
+			}
+
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseSSHArgs() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitDestination(t *testing.T) {
+	tests := []struct {
+		destination string
+		user        string
+		host        string
+		port        int
+	}{
+		{destination: "bastion", host: "bastion", port: 22},
+		{destination: "admin@bastion", user: "admin", host: "bastion", port: 22},
+		{destination: "ssh://bastion", host: "bastion", port: 22},
+		{destination: "ssh://admin@bastion:2222", user: "admin", host: "bastion", port: 2222},
+		{destination: "ssh://[2001:db8::1]:2222", host: "2001:db8::1", port: 2222},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.destination, func(t *testing.T) {
+			user, host, port, err := splitDestination(tt.destination)
+			if err != nil {
+				t.Fatalf("splitDestination() error = %v", err)
+			}
+
+			if user != tt.user || host != tt.host || port != tt.port {
+				t.Errorf("splitDestination() = %q, %q, %d, want %q, %q, %d", user, host, port, tt.user, tt.host, tt.port)
+			}
+		})
+	}
+
+	if _, _, _, err := splitDestination("ssh://bastion:ssh"); err == nil {
+		t.Error("splitDestination() with an invalid port error = nil")
+	}
+}
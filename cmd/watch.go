@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"port-jump/internal/options"
+	"port-jump/pkg/hotp"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:     "watch",
+	Aliases: []string{"dashboard"},
+	Short:   "Show a live dashboard of every jump and its current port",
+	Long: `Show a live dashboard of every jump and its current port.
+
+Every configured jump, and the jumps of every host entry, are listed with
+their current port, the port of the next window, and a countdown to the next
+rotation.
+
+Keys:
+  up/k, down/j  select a jump
+  c             copy the selected jump's current port
+  s, enter      open an ssh session to the selected host entry's jump
+  q, esc        quit`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !interactive() {
+			return reportError(cmd, errors.New("not running in a terminal, use 'port-jump get port --follow' instead"))
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			return reportError(cmd, fmt.Errorf("failed to find the port-jump executable: %v", err))
+		}
+
+		command, err := watchCommand(executable, opts)
+		if err != nil {
+			return reportError(cmd, err)
+		}
+
+		m := newWatchModel(command)
+		if len(m.rows) == 0 {
+			return reportError(cmd, errors.New("there are no jumps in the configuration file"))
+		}
+
+		if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+			return reportError(cmd, err)
+		}
+
+		return nil
+	},
+}
+
+// watchCommand returns the port-jump command line ssh sessions are opened
+// with, using the same configuration as o. Jumps read from the environment
+// are inherited by the spawned port-jump process.
+func watchCommand(executable string, o *options.Options) ([]string, error) {
+	if o.FromEnvironment() {
+		return []string{executable}, nil
+	}
+
+	configPath, err := o.ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if configPath, err = filepath.Abs(configPath); err != nil {
+		return nil, err
+	}
+
+	return []string{executable, "--config", configPath}, nil
+}
+
+// watchTickMsg updates the dashboard
+type watchTickMsg time.Time
+
+// watchSSHMsg is sent when an ssh session ends
+type watchSSHMsg struct{ err error }
+
+// watchRow is a jump shown on the dashboard
+type watchRow struct {
+	host *options.Host
+	jump *options.PortJump
+	totp *hotp.Hotp
+	err  error
+}
+
+// watchModel is the dashboard of every jump
+type watchModel struct {
+	rows   []watchRow
+	cursor int
+	now    time.Time
+	status string
+	bar    progress.Model
+	// command is the port-jump command line used to open ssh sessions
+	command []string
+}
+
+// newWatchModel returns the dashboard for every configured jump, and the
+// jumps of every host entry
+func newWatchModel(command []string) watchModel {
+	m := watchModel{
+		now:     time.Now(),
+		bar:     progress.New(progress.WithDefaultGradient(), progress.WithWidth(20), progress.WithoutPercentage()),
+		command: command,
+	}
+
+	add := func(host *options.Host, jump *options.PortJump) {
+		totp, err := jump.Totp()
+		m.rows = append(m.rows, watchRow{host: host, jump: jump, totp: totp, err: err})
+	}
+
+	for _, jump := range opts.Jumps {
+		add(nil, jump)
+	}
+
+	for _, host := range opts.Hosts {
+		for _, jump := range host.Jumps {
+			add(host, jump)
+		}
+	}
+
+	return m
+}
+
+// watchTick schedules the next dashboard update
+func watchTick() tea.Cmd {
+	return tea.Tick(time.Second/4, func(t time.Time) tea.Msg {
+		return watchTickMsg(t)
+	})
+}
+
+func (m watchModel) Init() tea.Cmd {
+	return watchTick()
+}
+
+func (m watchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case watchTickMsg:
+		m.now = time.Time(msg)
+		return m, watchTick()
+
+	case watchSSHMsg:
+		m.status = "ssh session ended"
+		if msg.err != nil {
+			m.status = fmt.Sprintf("ssh session ended: %v", msg.err)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.rows)-1 {
+				m.cursor++
+			}
+		case "c", "y":
+			m.status = m.copyPort()
+		case "s", "enter":
+			row := m.rows[m.cursor]
+			if row.host == nil {
+				m.status = "ssh needs a host entry, as there is no address for this jump"
+				return m, nil
+			}
+
+			args := append(append([]string{}, m.command[1:]...), "ssh", "--jump", row.jump.Name, row.host.Name)
+			return m, tea.ExecProcess(exec.Command(m.command[0], args...), func(err error) tea.Msg {
+				return watchSSHMsg{err: err}
+			})
+		}
+	}
+
+	return m, nil
+}
+
+// copyPort copies the selected jump's current port to the clipboard, and
+// returns the status to show
+func (m watchModel) copyPort() string {
+	row := m.rows[m.cursor]
+	if row.err != nil {
+		return fmt.Sprintf("no port to copy: %v", row.err)
+	}
+
+	port, err := row.totp.GenerateTCPPortAt(m.now)
+	if err != nil {
+		return fmt.Sprintf("no port to copy: %v", err)
+	}
+
+	// without a system clipboard, i.e. over ssh, ask the terminal to copy
+	if err := clipboard.WriteAll(fmt.Sprintf("%d", port)); err != nil {
+		termenv.Copy(fmt.Sprintf("%d", port))
+	}
+
+	return fmt.Sprintf("copied port %d of %s", port, m.name(row))
+}
+
+// name returns how row is referred to, as host/name for host entries
+func (m watchModel) name(row watchRow) string {
+	if row.host == nil {
+		return row.jump.Name
+	}
+
+	return row.host.Name + "/" + row.jump.Name
+}
+
+func (m watchModel) View() string {
+	var (
+		selectedStyle = lipgloss.NewStyle().Padding(0, 1).Bold(true).Foreground(lipgloss.Color("212"))
+		disabledStyle = lipgloss.NewStyle().Padding(0, 1).Foreground(lipgloss.Color("240"))
+		cellStyle     = lipgloss.NewStyle().Padding(0, 1)
+		mutedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	)
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99"))).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == 0:
+				return lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+			case row-1 == m.cursor:
+				return selectedStyle
+			case !m.rows[row-1].jump.Enabled:
+				return disabledStyle
+			default:
+				return cellStyle
+			}
+		}).
+		Headers("Host", "Jump", "Destination", "Enabled", "Port", "Next", "Rotation")
+
+	for _, row := range m.rows {
+		host := "-"
+		if row.host != nil {
+			host = row.host.Name
+		}
+
+		t.Row(append([]string{
+			host,
+			row.jump.Name,
+			fmt.Sprintf("%d", row.jump.DstPort),
+			fmt.Sprintf("%t", row.jump.Enabled),
+		}, m.ports(row)...)...)
+	}
+
+	status := m.status
+	if status == "" {
+		status = fmt.Sprintf("%d jumps", len(m.rows))
+	}
+
+	help := "↑/↓ select • c copy port • s ssh • q quit"
+
+	return t.Render() + "\n" + mutedStyle.Render(status) + "\n" + mutedStyle.Render(help) + "\n"
+}
+
+// ports returns the current port, next port and countdown columns of row
+func (m watchModel) ports(row watchRow) []string {
+	if row.err != nil {
+		return []string{"error", "", row.err.Error()}
+	}
+
+	start, end := row.totp.Window(m.now)
+
+	port, err := row.totp.GenerateTCPPortAt(m.now)
+	if err != nil {
+		return []string{"error", "", err.Error()}
+	}
+
+	next, err := row.totp.GenerateTCPPortAt(end)
+	if err != nil {
+		return []string{"error", "", err.Error()}
+	}
+
+	remaining := end.Sub(m.now)
+	percent := float64(remaining) / float64(end.Sub(start))
+	countdown := fmt.Sprintf("%s %3ds", m.bar.ViewAs(percent), int(remaining.Seconds()+0.999))
+
+	return []string{fmt.Sprintf("%d", port), fmt.Sprintf("%d", next), countdown}
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"port-jump/internal/options"
+)
+
+func TestWatchCommand(t *testing.T) {
+	relative, err := filepath.Abs("config.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts func() *options.Options
+		want []string
+	}{
+		{
+			name: "environment",
+			opts: func() *options.Options {
+				o := options.NewOptions()
+				o.UseEnvironment()
+				return o
+			},
+			want: []string{"/usr/bin/port-jump"},
+		},
+		{
+			name: "config file",
+			opts: func() *options.Options {
+				o := options.NewOptions()
+				o.SetConfigPath("/etc/port-jump/config.yml")
+				return o
+			},
+			want: []string{"/usr/bin/port-jump", "--config", "/etc/port-jump/config.yml"},
+		},
+		{
+			name: "relative config file",
+			opts: func() *options.Options {
+				o := options.NewOptions()
+				o.SetConfigPath("config.yml")
+				return o
+			},
+			want: []string{"/usr/bin/port-jump", "--config", relative},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := watchCommand("/usr/bin/port-jump", tt.opts())
+			if err != nil {
+				t.Fatalf("watchCommand() error = %v", err)
+			}
+
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("watchCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package bundle
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"port-jump/internal/options"
+	"port-jump/pkg/hotp"
+
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Kind identifies a document as a client bundle
+	Kind = "port-jump-bundle"
+	// Version is the bundle format version
+	Version = 1
+)
+
+// scrypt parameters used to derive encryption keys from passphrases
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// ErrPassphraseRequired is returned when opening an encrypted bundle without a passphrase
+var ErrPassphraseRequired = errors.New("bundle is encrypted, a passphrase is required")
+
+// Bundle is a self-contained description of a single jump, with everything
+// a client needs to derive the jump's current port.
+type Bundle struct {
+	Kind      string    `yaml:"kind"`
+	Version   int       `yaml:"version"`
+	Host      string    `yaml:"host,omitempty"`
+	Jump      Jump      `yaml:"jump"`
+	Algorithm Algorithm `yaml:"algorithm"`
+}
+
+// Jump is the client side description of a jump
+type Jump struct {
+	Name         string   `yaml:"name"`
+	Description  string   `yaml:"description,omitempty"`
+	Tags         []string `yaml:"tags,omitempty"`
+	DstPort      int      `yaml:"dstport"`
+	Interval     int64    `yaml:"interval"`
+	SharedSecret string   `yaml:"sharedsecret"`
+}
+
+// Algorithm describes how ports are derived from the shared secret
+type Algorithm struct {
+	Hash    string `yaml:"hash"`
+	MinPort int    `yaml:"minport"`
+	MaxPort int    `yaml:"maxport"`
+}
+
+// encrypted is the document format of a passphrase encrypted bundle
+type encrypted struct {
+	Kind       string     `yaml:"kind"`
+	Version    int        `yaml:"version"`
+	Encryption encryption `yaml:"encryption"`
+	Ciphertext binary     `yaml:"ciphertext"`
+}
+
+// encryption describes how an encrypted bundle was encrypted
+type encryption struct {
+	Cipher string `yaml:"cipher"`
+	KDF    string `yaml:"kdf"`
+	N      int    `yaml:"n"`
+	R      int    `yaml:"r"`
+	P      int    `yaml:"p"`
+	Salt   binary `yaml:"salt"`
+	Nonce  binary `yaml:"nonce"`
+}
+
+// binary is a byte slice encoded as a base64 string
+type binary []byte
+
+// MarshalYAML encodes b as a base64 string
+func (b binary) MarshalYAML() (interface{}, error) {
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// UnmarshalYAML decodes b from a base64 string
+func (b *binary) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("invalid base64 value: %v", err)
+	}
+
+	*b = data
+	return nil
+}
+
+// currentAlgorithm returns the algorithm parameters of this build
+func currentAlgorithm() Algorithm {
+	return Algorithm{
+		Hash:    hotp.Algorithm,
+		MinPort: hotp.MinPort,
+		MaxPort: hotp.MaxPort,
+	}
+}
+
+// New returns a bundle for jump. Shared secret references are exported as
+// their resolved value, so that the bundle is self-contained.
+func New(host string, jump *options.PortJump) *Bundle {
+	return &Bundle{
+		Kind:    Kind,
+		Version: Version,
+		Host:    host,
+		Jump: Jump{
+			Name:         jump.Name,
+			Description:  jump.Description,
+			Tags:         jump.Tags,
+			DstPort:      jump.DstPort,
+			Interval:     jump.Interval,
+			SharedSecret: jump.Secret(),
+		},
+		Algorithm: currentAlgorithm(),
+	}
+}
+
+// PortJump returns the bundle as a client side jump. Secrets that cannot
+// derive ports are refused, rather than failing once a port is needed.
+func (b *Bundle) PortJump() (*options.PortJump, error) {
+	if err := options.ValidateSecret(b.Jump.SharedSecret); err != nil {
+		return nil, fmt.Errorf("invalid secret: %v", err)
+	}
+
+	jump, err := options.NewPortJump(b.Jump.Name, b.Jump.DstPort, b.Jump.SharedSecret, b.Jump.Interval, true)
+	if err != nil {
+		return nil, err
+	}
+
+	jump.Description = b.Jump.Description
+	jump.Tags = b.Jump.Tags
+
+	return jump, nil
+}
+
+// validate checks that the bundle can be used by this build
+func (b *Bundle) validate() error {
+	if b.Kind != Kind {
+		return fmt.Errorf("not a port-jump bundle, kind is %q", b.Kind)
+	}
+
+	if b.Version > Version {
+		return fmt.Errorf("bundle version %d is newer than the supported version %d, please upgrade port-jump", b.Version, Version)
+	}
+
+	if b.Algorithm != currentAlgorithm() {
+		return fmt.Errorf("bundle uses unsupported algorithm parameters (%s, ports %d-%d)",
+			b.Algorithm.Hash, b.Algorithm.MinPort, b.Algorithm.MaxPort)
+	}
+
+	return nil
+}
+
+// Marshal encodes the bundle. If passphrase is not empty, the bundle is
+// encrypted with a key derived from it.
+func (b *Bundle) Marshal(passphrase string) ([]byte, error) {
+	plain, err := marshalYAML(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if passphrase == "" {
+		return plain, nil
+	}
+
+	enc := encryption{
+		Cipher: "aes-256-gcm",
+		KDF:    "scrypt",
+		N:      scryptN,
+		R:      scryptR,
+		P:      scryptP,
+		Salt:   make([]byte, 16),
+	}
+
+	if _, err := rand.Read(enc.Salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	aead, err := enc.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	enc.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(enc.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	return marshalYAML(&encrypted{
+		Kind:       Kind,
+		Version:    Version,
+		Encryption: enc,
+		Ciphertext: aead.Seal(nil, enc.Nonce, plain, []byte(Kind)),
+	})
+}
+
+// Unmarshal decodes a bundle, decrypting it with passphrase if it is encrypted
+func Unmarshal(data []byte, passphrase string) (*Bundle, error) {
+	var doc encrypted
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %v", err)
+	}
+
+	if doc.Kind != Kind {
+		return nil, fmt.Errorf("not a port-jump bundle, kind is %q", doc.Kind)
+	}
+
+	if doc.Ciphertext != nil {
+		if passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+
+		plain, err := doc.decrypt(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		data = plain
+	}
+
+	var b Bundle
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %v", err)
+	}
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// IsEncrypted reports if data is an encrypted bundle
+func IsEncrypted(data []byte) bool {
+	var doc encrypted
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+
+	return doc.Ciphertext != nil
+}
+
+// decrypt decrypts the bundle with a key derived from passphrase
+func (e *encrypted) decrypt(passphrase string) ([]byte, error) {
+	if e.Encryption.Cipher != "aes-256-gcm" || e.Encryption.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported bundle encryption %s with %s", e.Encryption.Cipher, e.Encryption.KDF)
+	}
+
+	aead, err := e.Encryption.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(e.Encryption.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid bundle nonce")
+	}
+
+	plain, err := aead.Open(nil, e.Encryption.Nonce, e.Ciphertext, []byte(Kind))
+	if err != nil {
+		return nil, errors.New("failed to decrypt bundle, is the passphrase correct?")
+	}
+
+	return plain, nil
+}
+
+// aead returns the AES-GCM cipher keyed with a key derived from passphrase
+func (e *encryption) aead(passphrase string) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), e.Salt, e.N, e.R, e.P, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// marshalYAML encodes v as YAML, indented like configuration files
+func marshalYAML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,127 @@
+package bundle
+
+import (
+	"strings"
+	"testing"
+
+	"port-jump/internal/options"
+)
+
+func testJump(t *testing.T) *options.PortJump {
+	t.Helper()
+
+	jump, err := options.NewPortJump("ssh", 22, "JBSWY3DPEHPK3PXP", 30, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jump.Description = "bastion ssh"
+	jump.Tags = []string{"prod", "eu"}
+
+	return jump
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	for _, passphrase := range []string{"", "correct horse"} {
+		data, err := New("bastion.example.com", testJump(t)).Marshal(passphrase)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+
+		if IsEncrypted(data) != (passphrase != "") {
+			t.Errorf("IsEncrypted() = %v with passphrase %q", IsEncrypted(data), passphrase)
+		}
+
+		if passphrase != "" && strings.Contains(string(data), "JBSWY3DPEHPK3PXP") {
+			t.Error("encrypted bundle contains the plain shared secret")
+		}
+
+		b, err := Unmarshal(data, passphrase)
+		if err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+
+		if b.Host != "bastion.example.com" {
+			t.Errorf("Host = %q, want bastion.example.com", b.Host)
+		}
+
+		jump, err := b.PortJump()
+		if err != nil {
+			t.Fatalf("PortJump() error = %v", err)
+		}
+
+		if jump.Name != "ssh" || jump.DstPort != 22 || jump.Interval != 30 || jump.SharedSecret != "JBSWY3DPEHPK3PXP" ||
+			jump.Description != "bastion ssh" || !jump.HasTag("eu") || !jump.Enabled {
+			t.Errorf("PortJump() = %+v, want the exported jump", jump)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	encrypted, err := New("", testJump(t)).Marshal("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		data       string
+		passphrase string
+		want       string
+	}{
+		{
+			name: "not yaml",
+			data: "{",
+			want: "failed to parse bundle",
+		},
+		{
+			name: "another kind",
+			data: "kind: something-else\n",
+			want: `not a port-jump bundle, kind is "something-else"`,
+		},
+		{
+			name: "newer version",
+			data: "kind: " + Kind + "\nversion: 99\n",
+			want: "please upgrade port-jump",
+		},
+		{
+			name: "other algorithm",
+			data: "kind: " + Kind + "\nversion: 1\nalgorithm:\n  hash: SHA256\n  minport: 1024\n  maxport: 65535\n",
+			want: "unsupported algorithm parameters (SHA256, ports 1024-65535)",
+		},
+		{
+			name: "encrypted without passphrase",
+			data: string(encrypted),
+			want: ErrPassphraseRequired.Error(),
+		},
+		{
+			name:       "encrypted with the wrong passphrase",
+			data:       string(encrypted),
+			passphrase: "battery staple",
+			want:       "is the passphrase correct?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.data), tt.passphrase)
+			if err == nil {
+				t.Fatalf("Unmarshal() error = nil, want %q", tt.want)
+			}
+
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Unmarshal() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPortJumpInvalidSecret(t *testing.T) {
+	for _, secret := range []string{"", "JBSWY3DP", "not base32 at all!!"} {
+		b := New("", testJump(t))
+		b.Jump.SharedSecret = secret
+
+		if _, err := b.PortJump(); err == nil || !strings.Contains(err.Error(), "invalid secret") {
+			t.Errorf("PortJump() with secret %q error = %v, want an invalid secret error", secret, err)
+		}
+	}
+}
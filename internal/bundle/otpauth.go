@@ -0,0 +1,138 @@
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// uriScheme is the scheme of otpauth URIs
+	uriScheme = "otpauth"
+	// uriIssuer is the issuer set in URIs, and stripped from labels when parsing
+	uriIssuer = "port-jump"
+	// defaultPeriod is the TOTP period authenticator apps assume
+	defaultPeriod = 30
+)
+
+// URI returns the bundle as an otpauth:// URI, as used by authenticator
+// apps. The standard secret, issuer, algorithm and period parameters are
+// set, and the port-jump specific details are carried as extra parameters.
+func (b *Bundle) URI() string {
+	q := url.Values{}
+	q.Set("secret", strings.ToUpper(b.Jump.SharedSecret))
+	q.Set("issuer", uriIssuer)
+	q.Set("algorithm", b.Algorithm.Hash)
+	q.Set("period", strconv.FormatInt(b.Jump.Interval, 10))
+	q.Set("dstport", strconv.Itoa(b.Jump.DstPort))
+	q.Set("minport", strconv.Itoa(b.Algorithm.MinPort))
+	q.Set("maxport", strconv.Itoa(b.Algorithm.MaxPort))
+
+	if b.Host != "" {
+		q.Set("host", b.Host)
+	}
+
+	if b.Jump.Description != "" {
+		q.Set("description", b.Jump.Description)
+	}
+
+	if len(b.Jump.Tags) > 0 {
+		q.Set("tags", strings.Join(b.Jump.Tags, ","))
+	}
+
+	u := url.URL{
+		Scheme:   uriScheme,
+		Host:     "totp",
+		Path:     "/" + uriIssuer + ":" + b.Jump.Name,
+		RawQuery: q.Encode(),
+	}
+
+	return u.String()
+}
+
+// IsURI reports if s looks like an otpauth:// URI
+func IsURI(s string) bool {
+	return strings.HasPrefix(strings.TrimSpace(s), uriScheme+"://")
+}
+
+// ParseURI returns a bundle from an otpauth:// URI. URIs from authenticator
+// apps carry no destination port, leaving it 0 for the caller to set. The
+// jump name is taken from the label, without any issuer prefix.
+func ParseURI(s string) (*Bundle, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse uri: %v", err)
+	}
+
+	if u.Scheme != uriScheme {
+		return nil, fmt.Errorf("not an %s uri, scheme is %q", uriScheme, u.Scheme)
+	}
+
+	if u.Host != "totp" {
+		return nil, fmt.Errorf("unsupported otp type %q, only totp is supported", u.Host)
+	}
+
+	q := u.Query()
+
+	b := &Bundle{
+		Kind:    Kind,
+		Version: Version,
+		Host:    q.Get("host"),
+		Jump: Jump{
+			Description:  q.Get("description"),
+			SharedSecret: strings.TrimRight(strings.ToUpper(q.Get("secret")), "="),
+			Interval:     defaultPeriod,
+		},
+		Algorithm: currentAlgorithm(),
+	}
+
+	if b.Jump.SharedSecret == "" {
+		return nil, errors.New("uri has no secret")
+	}
+
+	// labels are issuer:account, where the account names the jump
+	label := strings.TrimPrefix(u.Path, "/")
+	if _, account, ok := strings.Cut(label, ":"); ok {
+		label = account
+	}
+	b.Jump.Name = strings.TrimSpace(label)
+
+	if tags := q.Get("tags"); tags != "" {
+		b.Jump.Tags = strings.Split(tags, ",")
+	}
+
+	ints := []struct {
+		param string
+		value *int
+	}{
+		{"dstport", &b.Jump.DstPort},
+		{"minport", &b.Algorithm.MinPort},
+		{"maxport", &b.Algorithm.MaxPort},
+	}
+
+	for _, i := range ints {
+		if v := q.Get(i.param); v != "" {
+			if *i.value, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("invalid %s %q in uri", i.param, v)
+			}
+		}
+	}
+
+	if v := q.Get("period"); v != "" {
+		if b.Jump.Interval, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid period %q in uri", v)
+		}
+	}
+
+	if v := q.Get("algorithm"); v != "" {
+		b.Algorithm.Hash = strings.ToUpper(v)
+	}
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
@@ -0,0 +1,96 @@
+package bundle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestURIRoundTrip(t *testing.T) {
+	uri := New("bastion.example.com", testJump(t)).URI()
+
+	if !IsURI(uri) {
+		t.Fatalf("IsURI(%q) = false", uri)
+	}
+
+	b, err := ParseURI(uri)
+	if err != nil {
+		t.Fatalf("ParseURI() error = %v", err)
+	}
+
+	if b.Host != "bastion.example.com" || b.Jump.Name != "ssh" || b.Jump.DstPort != 22 || b.Jump.Interval != 30 ||
+		b.Jump.SharedSecret != "JBSWY3DPEHPK3PXP" || b.Jump.Description != "bastion ssh" || len(b.Jump.Tags) != 2 {
+		t.Errorf("ParseURI() = %+v, want the exported jump", b)
+	}
+}
+
+func TestParseAuthenticatorURI(t *testing.T) {
+	// authenticator apps use lower case, padded secrets and know no port-jump parameters
+	b, err := ParseURI(" otpauth://totp/Example:alice?secret=jbswy3dpehpk3pxp%3D%3D%3D%3D&issuer=Example ")
+	if err != nil {
+		t.Fatalf("ParseURI() error = %v", err)
+	}
+
+	if b.Jump.Name != "alice" {
+		t.Errorf("Name = %q, want alice", b.Jump.Name)
+	}
+
+	if b.Jump.SharedSecret != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("SharedSecret = %q, want JBSWY3DPEHPK3PXP", b.Jump.SharedSecret)
+	}
+
+	if b.Jump.Interval != defaultPeriod || b.Jump.DstPort != 0 {
+		t.Errorf("Interval = %d, DstPort = %d, want %d and 0", b.Jump.Interval, b.Jump.DstPort, defaultPeriod)
+	}
+}
+
+func TestParseURIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		want string
+	}{
+		{
+			name: "other scheme",
+			uri:  "https://totp/port-jump:ssh?secret=JBSWY3DPEHPK3PXP",
+			want: `not an otpauth uri, scheme is "https"`,
+		},
+		{
+			name: "hotp",
+			uri:  "otpauth://hotp/port-jump:ssh?secret=JBSWY3DPEHPK3PXP&counter=0",
+			want: `unsupported otp type "hotp"`,
+		},
+		{
+			name: "no secret",
+			uri:  "otpauth://totp/port-jump:ssh",
+			want: "uri has no secret",
+		},
+		{
+			name: "invalid port",
+			uri:  "otpauth://totp/port-jump:ssh?secret=JBSWY3DPEHPK3PXP&dstport=ssh",
+			want: `invalid dstport "ssh" in uri`,
+		},
+		{
+			name: "invalid period",
+			uri:  "otpauth://totp/port-jump:ssh?secret=JBSWY3DPEHPK3PXP&period=soon",
+			want: `invalid period "soon" in uri`,
+		},
+		{
+			name: "other algorithm",
+			uri:  "otpauth://totp/port-jump:ssh?secret=JBSWY3DPEHPK3PXP&algorithm=sha256",
+			want: "unsupported algorithm parameters (SHA256",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseURI(tt.uri)
+			if err == nil {
+				t.Fatalf("ParseURI() error = nil, want %q", tt.want)
+			}
+
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseURI() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+
+	"port-jump/internal/options"
+)
+
+// DefaultMargin is how close to a window boundary the adjacent window's port is tried too
+const DefaultMargin = 5 * time.Second
+
+// Candidates returns the ports to try for jump at t. The current window's
+// port comes first. Within margin of a window boundary, the port of the
+// adjacent window follows, in case the clocks of client and server differ.
+func Candidates(jump *options.PortJump, t time.Time, margin time.Duration) ([]int, error) {
+	gen, err := jump.Totp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get totp generator handle: %v", err)
+	}
+
+	port, err := gen.GenerateTCPPortAt(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TCP port: %v", err)
+	}
+
+	ports := []int{port}
+
+	interval := time.Duration(jump.Interval) * time.Second
+	elapsed := time.Duration(t.Unix()%jump.Interval) * time.Second
+
+	var adjacent time.Time
+	switch {
+	case elapsed < margin:
+		adjacent = t.Add(-interval)
+	case interval-elapsed <= margin:
+		adjacent = t.Add(interval)
+	default:
+		return ports, nil
+	}
+
+	other, err := gen.GenerateTCPPortAt(adjacent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TCP port: %v", err)
+	}
+
+	if other != port {
+		ports = append(ports, other)
+	}
+
+	return ports, nil
+}
+
+// Dial connects to address on the first of ports that does not refuse the
+// connection, returning the connection and the port used. Other errors,
+// such as timeouts, are returned immediately as trying another port will
+// not help.
+func Dial(ctx context.Context, address string, ports []int, timeout time.Duration) (net.Conn, int, error) {
+	if len(ports) == 0 {
+		return nil, 0, errors.New("no ports to connect to")
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+
+	var err error
+	for _, port := range ports {
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+		if err == nil {
+			return conn, port, nil
+		}
+
+		if !Refused(err) {
+			return nil, port, err
+		}
+	}
+
+	return nil, ports[0], err
+}
+
+// Bridge copies in to conn, and conn to out, until the peer has no more
+// data. When in has no more data, conn is closed for writing, so that the
+// peer sees the end of the stream while its response is still read.
+func Bridge(conn net.Conn, in io.Reader, out io.Writer) error {
+	go func() {
+		io.Copy(conn, in)
+
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+	}()
+
+	_, err := io.Copy(out, conn)
+
+	return err
+}
+
+// Refused reports if err is a refused connection
+func Refused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
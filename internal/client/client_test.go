@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"port-jump/internal/options"
+)
+
+func TestCandidates(t *testing.T) {
+	jump, err := options.NewPortJump("ssh", 22, "JBSWY3DPEHPK3PXP", 30, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen, err := jump.Totp()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a window starts every 30 seconds since the epoch
+	start := time.Unix(1_700_000_010, 0)
+	portAt := func(t *testing.T, at time.Time) int {
+		t.Helper()
+
+		port, err := gen.GenerateTCPPortAt(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return port
+	}
+
+	previous := portAt(t, start.Add(-time.Second))
+	current := portAt(t, start)
+	next := portAt(t, start.Add(30*time.Second))
+
+	if previous == current || current == next {
+		t.Fatal("adjacent windows share a port, pick another window for the test")
+	}
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		margin  time.Duration
+		want    []int
+	}{
+		{name: "window start", elapsed: 0, margin: DefaultMargin, want: []int{current, previous}},
+		{name: "just inside the start margin", elapsed: 4 * time.Second, margin: DefaultMargin, want: []int{current, previous}},
+		{name: "at the start margin", elapsed: 5 * time.Second, margin: DefaultMargin, want: []int{current}},
+		{name: "middle of the window", elapsed: 15 * time.Second, margin: DefaultMargin, want: []int{current}},
+		{name: "just outside the end margin", elapsed: 24 * time.Second, margin: DefaultMargin, want: []int{current}},
+		{name: "at the end margin", elapsed: 25 * time.Second, margin: DefaultMargin, want: []int{current, next}},
+		{name: "window end", elapsed: 29 * time.Second, margin: DefaultMargin, want: []int{current, next}},
+		{name: "no margin at the start", elapsed: 0, margin: 0, want: []int{current}},
+		{name: "no margin at the end", elapsed: 29 * time.Second, margin: 0, want: []int{current}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Candidates(jump, start.Add(tt.elapsed), tt.margin)
+			if err != nil {
+				t.Fatalf("Candidates() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Candidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDial(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	open := listener.Addr().(*net.TCPAddr).Port
+
+	// a port that was just released refuses connections
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	conn, port, err := Dial(context.Background(), "127.0.0.1", []int{refused, open}, time.Second)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	conn.Close()
+
+	if port != open {
+		t.Errorf("Dial() port = %d, want the open port %d", port, open)
+	}
+
+	_, port, err = Dial(context.Background(), "127.0.0.1", []int{refused}, time.Second)
+	if !Refused(err) {
+		t.Errorf("Dial() error = %v, want a refused connection", err)
+	}
+
+	if port != refused {
+		t.Errorf("Dial() port = %d, want %d", port, refused)
+	}
+
+	if _, _, err := Dial(context.Background(), "127.0.0.1", nil, time.Second); err == nil {
+		t.Error("Dial() without ports error = nil")
+	}
+}
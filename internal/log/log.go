@@ -11,7 +11,7 @@ func Setup(debug bool) {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 
-	logger := log.Output(zerolog.ConsoleWriter{Out: os.Stdout}).With().
+	logger := log.Output(zerolog.ConsoleWriter{Out: os.Stderr}).With().
 		Timestamp().
 		Logger()
 
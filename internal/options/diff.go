@@ -0,0 +1,68 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Change is a single changed field of a jump
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// String returns a human readable description of the change
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Field, c.Old, c.New)
+}
+
+// maskedSecret is shown instead of shared secrets in changes
+const maskedSecret = "********"
+
+// Clone returns a copy of the jump
+func (p *PortJump) Clone() *PortJump {
+	c := *p
+	c.Tags = append([]string(nil), p.Tags...)
+
+	return &c
+}
+
+// DiffJump returns the fields that differ between old and new. Shared
+// secrets are masked, unless they are references.
+func DiffJump(old *PortJump, new *PortJump) []Change {
+	var changes []Change
+
+	add := func(field, o, n string) {
+		if o != n {
+			changes = append(changes, Change{Field: field, Old: o, New: n})
+		}
+	}
+
+	add("name", old.Name, new.Name)
+	add("description", old.Description, new.Description)
+	add("tags", strings.Join(old.Tags, ","), strings.Join(new.Tags, ","))
+	add("interface", old.Interface, new.Interface)
+	add("enabled", fmt.Sprint(old.Enabled), fmt.Sprint(new.Enabled))
+	add("dstport", fmt.Sprint(old.DstPort), fmt.Sprint(new.DstPort))
+	add("interval", fmt.Sprint(old.Interval), fmt.Sprint(new.Interval))
+
+	if old.SharedSecret != new.SharedSecret {
+		changes = append(changes, Change{
+			Field: "sharedsecret",
+			Old:   maskSecret(old.SharedSecret),
+			New:   maskSecret(new.SharedSecret),
+		})
+	}
+
+	return changes
+}
+
+// maskSecret hides a shared secret, unless it is a reference
+func maskSecret(secret string) string {
+	if secret == "" || IsVaultRef(secret) {
+		return secret
+	}
+
+	return maskedSecret
+}
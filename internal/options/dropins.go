@@ -0,0 +1,124 @@
+package options
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dropInFile is the structure of a conf.d file
+type dropInFile struct {
+	Version int         `yaml:"version"`
+	Jumps   []*PortJump `yaml:"jumps"`
+}
+
+// dropInFiles returns the sorted list of conf.d files next to configPath
+func dropInFiles(configPath string) ([]string, error) {
+	var files []string
+
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(filepath.Dir(configPath), dropInDir, pattern))
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, matches...)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// loadDropIns merges the jumps from every conf.d file into the options.
+// Only the jumps key is read from drop-in files.
+func (o *Options) loadDropIns(configPath string) error {
+	files, err := dropInFiles(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to list conf.d files: %v", err)
+	}
+
+	o.dropIns = files
+	o.dropInsLoaded = make(map[string][]byte, len(files))
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read drop-in config file %s: %v", file, err)
+		}
+
+		if err := o.trackVersion(file, data); err != nil {
+			return err
+		}
+
+		var dropIn dropInFile
+		if err := yaml.Unmarshal(data, &dropIn); err != nil {
+			return fmt.Errorf("failed to unmarshal drop-in config file %s: %v", file, err)
+		}
+
+		// remember what was loaded, so that files are only written back when changed
+		version, err := schemaVersion(file, data)
+		if err != nil {
+			return err
+		}
+
+		loaded, err := encodeYAML(&dropInFile{Version: version, Jumps: dropIn.Jumps})
+		if err != nil {
+			return fmt.Errorf("failed to encode drop-in config file %s: %v", file, err)
+		}
+		o.dropInsLoaded[file] = loaded
+
+		for _, jump := range dropIn.Jumps {
+			jump.source = file
+		}
+
+		o.Jumps = append(o.Jumps, dropIn.Jumps...)
+	}
+
+	return nil
+}
+
+// saveDropIns writes jumps that came from conf.d files back to their files.
+// Drop-ins are shipped by others, so a file is only written when its jumps
+// changed or it was migrated, and it keeps its permissions.
+func (o *Options) saveDropIns() error {
+	for _, file := range o.dropIns {
+		data, err := encodeYAML(&dropInFile{Version: CurrentVersion, Jumps: o.jumpsFrom(file)})
+		if err != nil {
+			return fmt.Errorf("failed to encode drop-in config file %s: %v", file, err)
+		}
+
+		if bytes.Equal(data, o.dropInsLoaded[file]) {
+			continue
+		}
+
+		mode := os.FileMode(0600)
+		if info, err := os.Stat(file); err == nil {
+			mode = info.Mode().Perm()
+		}
+
+		if err := WriteFileAtomic(file, data, mode); err != nil {
+			return fmt.Errorf("failed to write drop-in config file %s: %v", file, err)
+		}
+
+		o.dropInsLoaded[file] = data
+	}
+
+	return nil
+}
+
+// jumpsFrom returns the jumps loaded from source
+func (o *Options) jumpsFrom(source string) []*PortJump {
+	jumps := make([]*PortJump, 0, len(o.Jumps))
+	for _, jump := range o.Jumps {
+		if jump.source == source {
+			jumps = append(jumps, jump)
+		}
+	}
+
+	return jumps
+}
@@ -0,0 +1,108 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDropInsOnlyWrittenWhenChanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+	team := filepath.Join(dir, dropInDir, "team.yml")
+
+	writeTestFile(t, path, 0600, `version: 4
+jumps:
+  - name: ssh
+    enabled: true
+    dstport: 22
+    interval: 30
+    sharedsecret: JBSWY3DPEHPK3PXP
+`)
+
+	teamConfig := `# shipped by the team
+version: 4
+jumps:
+  - name: team
+    enabled: true
+    dstport: 2222
+    interval: 30
+    sharedsecret: JBSWY3DPEHPK3PXP
+`
+	writeTestFile(t, team, 0644, teamConfig)
+
+	o, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if o.Jump("team") == nil || o.Jump("team").Source() != team {
+		t.Fatalf("jump team not loaded from %s", team)
+	}
+
+	// changing a jump of the main file leaves the drop-in alone
+	err = o.Update(func(o *Options) error {
+		o.Jump("ssh").Enabled = false
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if got := readTestFile(t, team); got != teamConfig {
+		t.Errorf("unchanged drop-in was rewritten to:\n%s", got)
+	}
+
+	// changing a jump of the drop-in rewrites it, keeping its permissions
+	err = o.Update(func(o *Options) error {
+		o.Jump("team").Enabled = false
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if got := readTestFile(t, team); !strings.Contains(got, "enabled: false") {
+		t.Errorf("changed drop-in was not rewritten:\n%s", got)
+	}
+
+	info, err := os.Stat(team)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("drop-in mode = %o, want 644", info.Mode().Perm())
+	}
+}
+
+// writeTestFile writes content to path, creating its directory
+func writeTestFile(t *testing.T, path string, perm os.FileMode, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+
+	// WriteFile is subject to the umask
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readTestFile returns the content of path
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
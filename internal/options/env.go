@@ -0,0 +1,175 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// envJumps holds every jump as a single JSON (or YAML) list
+	envJumps = "PORTJUMP_JUMPS"
+	// envJumpPrefix prefixes per-jump variables, i.e. PORTJUMP_JUMP_0_DSTPORT
+	envJumpPrefix = "PORTJUMP_JUMP_"
+)
+
+// ErrReadOnly is returned when saving configuration that was not read from a file
+var ErrReadOnly = errors.New("configuration is read from the environment and cannot be saved")
+
+// EnvConfigured reports if jumps are defined in the environment
+func EnvConfigured() bool {
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if key == envJumps || strings.HasPrefix(key, envJumpPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UseEnvironment makes Load read jumps from environment variables instead of
+// a configuration file. Options in this mode cannot be saved.
+func (o *Options) UseEnvironment() {
+	o.env = true
+}
+
+// FromEnvironment reports if the options are read from environment variables
+func (o *Options) FromEnvironment() bool {
+	return o.env
+}
+
+// loadEnv reads jumps from environ. Jumps can be defined as a single list in
+// PORTJUMP_JUMPS, and/or one variable per field as PORTJUMP_JUMP_<n>_<FIELD>.
+// Jumps defined in the environment are enabled and use DefaultInterval unless
+// stated otherwise. Jumps that fail validation are rejected.
+func (o *Options) loadEnv(environ []string) error {
+	o.Vault = nil
+	o.Jumps = nil
+	o.Hosts = nil
+
+	indexed := make(map[int]*PortJump)
+
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+
+		switch {
+		case key == envJumps:
+			var entries []yaml.Node
+			if err := yaml.Unmarshal([]byte(value), &entries); err != nil {
+				return fmt.Errorf("failed to parse %s: %v", envJumps, err)
+			}
+
+			for i, entry := range entries {
+				// null entries would decode to nil jumps
+				if entry.Kind != yaml.MappingNode {
+					return fmt.Errorf("invalid jump %d in %s, expected an object", i, envJumps)
+				}
+
+				jump := &PortJump{}
+				if err := entry.Decode(jump); err != nil {
+					return fmt.Errorf("invalid jump %d in %s: %v", i, envJumps, err)
+				}
+
+				// decode again to find jumps that do not set enabled
+				var fields map[string]interface{}
+				if err := entry.Decode(&fields); err != nil {
+					return fmt.Errorf("invalid jump %d in %s: %v", i, envJumps, err)
+				}
+
+				if _, ok := fields["enabled"]; !ok {
+					jump.Enabled = true
+				}
+
+				o.Jumps = append(o.Jumps, jump)
+			}
+
+		case strings.HasPrefix(key, envJumpPrefix):
+			index, field, ok := strings.Cut(strings.TrimPrefix(key, envJumpPrefix), "_")
+			if !ok {
+				return fmt.Errorf("invalid variable %s, expected %s<n>_<FIELD>", key, envJumpPrefix)
+			}
+
+			i, err := strconv.Atoi(index)
+			if err != nil {
+				return fmt.Errorf("invalid jump index in %s: %v", key, err)
+			}
+
+			jump, ok := indexed[i]
+			if !ok {
+				jump = &PortJump{Enabled: true}
+				indexed[i] = jump
+			}
+
+			if err := setEnvField(jump, field, value); err != nil {
+				return fmt.Errorf("invalid value for %s: %v", key, err)
+			}
+		}
+	}
+
+	indexes := make([]int, 0, len(indexed))
+	for i := range indexed {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	for _, i := range indexes {
+		o.Jumps = append(o.Jumps, indexed[i])
+	}
+
+	for _, jump := range o.Jumps {
+		if jump.Interval == 0 {
+			jump.Interval = DefaultInterval
+		}
+	}
+
+	o.assignNames()
+
+	o.resolveSecrets()
+
+	var invalid []string
+	for _, problem := range o.Validate() {
+		if problem.Severity == SeverityError {
+			invalid = append(invalid, problem.String())
+		}
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid jumps in the environment: %s", strings.Join(invalid, "; "))
+	}
+
+	return nil
+}
+
+// setEnvField sets a single jump field from an environment variable value
+func setEnvField(jump *PortJump, field string, value string) error {
+	var err error
+
+	switch strings.ToUpper(field) {
+	case "NAME":
+		jump.Name = value
+	case "DESCRIPTION":
+		jump.Description = value
+	case "TAGS":
+		jump.Tags = strings.Split(value, ",")
+	case "INTERFACE":
+		jump.Interface = value
+	case "ENABLED":
+		jump.Enabled, err = strconv.ParseBool(value)
+	case "DSTPORT":
+		jump.DstPort, err = strconv.Atoi(value)
+	case "INTERVAL":
+		jump.Interval, err = strconv.ParseInt(value, 10, 64)
+	case "SHAREDSECRET":
+		jump.SharedSecret = value
+	default:
+		return fmt.Errorf("unknown jump field %s", field)
+	}
+
+	return err
+}
@@ -0,0 +1,131 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	o := NewOptions()
+	err := o.loadEnv([]string{
+		"HOME=/root",
+		"PORTJUMP_JUMP_1_DSTPORT=443",
+		"PORTJUMP_JUMP_1_SHAREDSECRET=HPQY7R45TFSZWTST",
+		"PORTJUMP_JUMP_1_ENABLED=false",
+		"PORTJUMP_JUMP_0_NAME=ssh",
+		"PORTJUMP_JUMP_0_DSTPORT=22",
+		"PORTJUMP_JUMP_0_INTERVAL=60",
+		"PORTJUMP_JUMP_0_TAGS=prod,eu",
+		"PORTJUMP_JUMP_0_SHAREDSECRET=FWX2CC3PLA4ZYGCI",
+		`PORTJUMP_JUMPS=[{"name": "web", "dstport": 80, "sharedsecret": "JBSWY3DPEHPK3PXP"}]`,
+	})
+	if err != nil {
+		t.Fatalf("loadEnv() error = %v", err)
+	}
+
+	if len(o.Jumps) != 3 {
+		t.Fatalf("loaded %d jumps, want 3", len(o.Jumps))
+	}
+
+	web := o.Jump("web")
+	if web == nil || !web.Enabled || web.Interval != DefaultInterval {
+		t.Errorf("web = %+v, want enabled with the default interval", web)
+	}
+
+	ssh := o.Jump("ssh")
+	if ssh == nil || !ssh.Enabled || ssh.Interval != 60 || !ssh.HasTag("eu") {
+		t.Errorf("ssh = %+v, want enabled, tagged eu, with interval 60", ssh)
+	}
+
+	// indexed jumps are appended in index order, after PORTJUMP_JUMPS
+	unnamed := o.Jumps[2]
+	if unnamed.Name == "" || unnamed.Enabled || unnamed.DstPort != 443 || unnamed.Interval != DefaultInterval {
+		t.Errorf("jump 1 = %+v, want a named, disabled jump to 443 with the default interval", unnamed)
+	}
+}
+
+func TestLoadEnvSharedDestination(t *testing.T) {
+	o := NewOptions()
+	err := o.loadEnv([]string{
+		`PORTJUMP_JUMPS=[{"name": "ops", "dstport": 22, "sharedsecret": "JBSWY3DPEHPK3PXP"}, {"name": "dev", "dstport": 22, "sharedsecret": "FWX2CC3PLA4ZYGCI"}]`,
+	})
+	if err != nil {
+		t.Fatalf("loadEnv() error = %v, want jumps per client group to load", err)
+	}
+
+	if o.Jump("ops") == nil || o.Jump("dev") == nil {
+		t.Errorf("loaded %d jumps, want ops and dev", len(o.Jumps))
+	}
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		environ []string
+		want    string
+	}{
+		{
+			name:    "missing destination port",
+			environ: []string{"PORTJUMP_JUMP_0_SHAREDSECRET=FWX2CC3PLA4ZYGCI"},
+			want:    "dstport: port 0 is not between 1 and 65535",
+		},
+		{
+			name:    "missing secret",
+			environ: []string{"PORTJUMP_JUMP_0_DSTPORT=22"},
+			want:    "sharedsecret: secret cannot be empty",
+		},
+		{
+			name:    "invalid secret",
+			environ: []string{"PORTJUMP_JUMP_0_DSTPORT=22", "PORTJUMP_JUMP_0_SHAREDSECRET=not base32!"},
+			want:    "secret is not valid unpadded base32",
+		},
+		{
+			name:    "invalid port",
+			environ: []string{"PORTJUMP_JUMP_0_DSTPORT=ssh"},
+			want:    "invalid value for PORTJUMP_JUMP_0_DSTPORT",
+		},
+		{
+			name:    "unknown field",
+			environ: []string{"PORTJUMP_JUMP_0_PORT=22"},
+			want:    "unknown jump field PORT",
+		},
+		{
+			name:    "invalid list",
+			environ: []string{"PORTJUMP_JUMPS={"},
+			want:    "failed to parse PORTJUMP_JUMPS",
+		},
+		{
+			name:    "not a list",
+			environ: []string{`PORTJUMP_JUMPS={"dstport": 22}`},
+			want:    "failed to parse PORTJUMP_JUMPS",
+		},
+		{
+			name:    "null jump",
+			environ: []string{`PORTJUMP_JUMPS=[{"dstport": 22, "sharedsecret": "JBSWY3DPEHPK3PXP"}, null]`},
+			want:    "invalid jump 1 in PORTJUMP_JUMPS, expected an object",
+		},
+		{
+			name:    "scalar jump",
+			environ: []string{"PORTJUMP_JUMPS=[22]"},
+			want:    "invalid jump 0 in PORTJUMP_JUMPS, expected an object",
+		},
+		{
+			name:    "invalid field",
+			environ: []string{`PORTJUMP_JUMPS=[{"dstport": "ssh"}]`},
+			want:    "invalid jump 0 in PORTJUMP_JUMPS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewOptions().loadEnv(tt.environ)
+			if err == nil {
+				t.Fatalf("loadEnv() error = nil, want %q", tt.want)
+			}
+
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("loadEnv() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package options
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFile loads the configuration file at path into a new, independent Options value
+func LoadFile(path string) (*Options, error) {
+	o := NewOptions()
+	o.SetConfigPath(path)
+
+	if err := o.Load(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// configPath returns the path where configuration should live.
+// if the config directory does not exist, it will be created.
+func (o *Options) configPath() (string, error) {
+	p, err := o.ConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(p); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return "", fmt.Errorf("failed to create config directory: %v", err)
+		}
+	}
+
+	return p, nil
+}
+
+// Load loads configuration from the config file. Any previously loaded
+// configuration in o is replaced.
+func (o *Options) Load() error {
+	if o.env {
+		return o.loadEnv(os.Environ())
+	}
+
+	configPath, err := o.configPath()
+	if err != nil {
+		return err
+	}
+
+	if err := o.read(configPath); err != nil {
+		return err
+	}
+
+	o.migrateErr = nil
+	if len(o.outdated) > 0 {
+		// commands that cannot write the files, i.e. unprivileged ones reading
+		// the system configuration, use them as migrated in memory
+		o.migrateErr = o.migrateFiles(configPath)
+	}
+
+	o.resolveSecrets()
+
+	return nil
+}
+
+// migrateFiles reads configPath again while holding its lock, and saves the
+// outdated files in the current schema version
+func (o *Options) migrateFiles(configPath string) error {
+	unlock, err := lockFile(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := o.read(configPath); err != nil {
+		return err
+	}
+
+	return o.saveMigrated(configPath)
+}
+
+// Reload returns freshly loaded Options, read from the same configuration
+// file or environment as o.
+func (o *Options) Reload() (*Options, error) {
+	reloaded := NewOptions()
+	reloaded.LogDebug = o.LogDebug
+	reloaded.path = o.path
+	reloaded.env = o.env
+
+	if err := reloaded.Load(); err != nil {
+		return nil, err
+	}
+
+	return reloaded, nil
+}
+
+// read reads configPath and its drop-ins into o, migrating older schema
+// versions in memory. Shared secret references are left unresolved.
+func (o *Options) read(configPath string) error {
+	loaded := Options{}
+	o.outdated = nil
+
+	// If the config file does not exist, only drop-ins are read as this is
+	// expected on the first run.
+	data, err := os.ReadFile(configPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	if len(data) > 0 {
+		if err := o.trackVersion(configPath, data); err != nil {
+			return err
+		}
+	}
+
+	if err := yaml.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to unmarshal config into options struct: %v", err)
+	}
+
+	o.Version = CurrentVersion
+	o.Vault = loaded.Vault
+	o.Jumps = loaded.Jumps
+	o.Hosts = loaded.Hosts
+
+	if err := o.loadDropIns(configPath); err != nil {
+		return err
+	}
+
+	return o.migrate()
+}
+
+// saveMigrated backs up and rewrites files that were migrated from an older schema version
+func (o *Options) saveMigrated(configPath string) error {
+	if len(o.outdated) == 0 {
+		return nil
+	}
+
+	if err := o.backupOutdated(); err != nil {
+		return err
+	}
+
+	if err := o.save(configPath); err != nil {
+		return err
+	}
+
+	o.outdated = nil
+
+	return nil
+}
+
+// Save saves configuration to the config file
+func (o *Options) Save() error {
+	if o.env {
+		return ErrReadOnly
+	}
+
+	configPath, err := o.configPath()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return o.save(configPath)
+}
+
+// Update locks the config file, reloads it so that changes made by other
+// processes are not lost, applies fn and saves the result.
+func (o *Options) Update(fn func(o *Options) error) error {
+	if o.env {
+		return ErrReadOnly
+	}
+
+	configPath, err := o.configPath()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// references are not resolved, editing does not need their values
+	if err := o.read(configPath); err != nil {
+		return err
+	}
+
+	if err := o.backupOutdated(); err != nil {
+		return err
+	}
+	o.outdated = nil
+
+	if err := fn(o); err != nil {
+		return err
+	}
+
+	return o.save(configPath)
+}
+
+// save writes o to configPath, and jumps from conf.d files back to where they came from
+func (o *Options) save(configPath string) error {
+	out := Options{
+		Version: CurrentVersion,
+		Vault:   o.Vault,
+		Jumps:   o.jumpsFrom(""),
+		Hosts:   o.Hosts,
+	}
+
+	if err := writeYAML(configPath, &out); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+
+	return o.saveDropIns()
+}
+
+// writeYAML atomically writes v as YAML to path with 0600 permissions
+func writeYAML(path string, v interface{}) error {
+	data, err := encodeYAML(v)
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(path, data, 0600)
+}
+
+// encodeYAML returns v as YAML, the way configuration files are written
+func encodeYAML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers never see a partially written file. If path is
+// a symlink, its target is written and the link kept.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	// write to the target of a symlink, i.e. a file kept in a dotfiles or
+	// configuration management repository, instead of replacing the link
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	// cleanup the temporary file if anything below fails
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set file permissions: %v", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,248 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveAndLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "port-jump", "config.yml")
+
+	jump, err := NewPortJump("ssh", 22, "JBSWY3DPEHPK3PXP", 30, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jump.Tags = []string{"prod"}
+
+	o := NewOptions()
+	o.SetConfigPath(path)
+	o.Jumps = append(o.Jumps, jump)
+
+	if err := o.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("config mode = %o, want 600", info.Mode().Perm())
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if loaded.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", loaded.Version, CurrentVersion)
+	}
+
+	got := loaded.Jump("ssh")
+	if got == nil {
+		t.Fatal("jump ssh not loaded")
+	}
+
+	if got.DstPort != 22 || got.Interval != 30 || !got.Enabled || got.Secret() != "JBSWY3DPEHPK3PXP" || !got.HasTag("prod") {
+		t.Errorf("loaded jump = %+v, want the saved one", got)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	o, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if len(o.Jumps) != 0 {
+		t.Errorf("Jumps = %v, want none", o.Jumps)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("loading created %s", path)
+	}
+}
+
+func TestUpdateKeepsConcurrentChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	writeTestFile(t, path, 0600, `version: 4
+jumps:
+  - name: ssh
+    enabled: true
+    dstport: 22
+    interval: 30
+    sharedsecret: JBSWY3DPEHPK3PXP
+`)
+
+	a, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = a.Update(func(o *Options) error {
+		jump, err := NewPortJump("web", 443, "JBSWY3DPEHPK3PXP", 30, true)
+		if err != nil {
+			return err
+		}
+
+		o.Jumps = append(o.Jumps, jump)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// b was loaded before web was added, and must not drop it
+	err = b.Update(func(o *Options) error {
+		o.Jump("ssh").Enabled = false
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Jump("web") == nil {
+		t.Error("jump web added by a concurrent update was lost")
+	}
+
+	if ssh := loaded.Jump("ssh"); ssh == nil || ssh.Enabled {
+		t.Error("jump ssh was not disabled")
+	}
+}
+
+func TestLoadMigratesOutdatedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	legacy := `jumps:
+  - enabled: true
+    dstport: 22
+    interval: 30
+    sharedsecret: JBSWY3DPEHPK3PXP
+`
+	writeTestFile(t, path, 0600, legacy)
+
+	o, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if len(o.Jumps) != 1 || o.Jumps[0].Name == "" {
+		t.Fatalf("Jumps = %+v, want one named jump", o.Jumps)
+	}
+
+	if got := readTestFile(t, path); !strings.HasPrefix(got, "version: 4\n") {
+		t.Errorf("migrated file:\n%s\nwant it to start with version: 4", got)
+	}
+
+	backups := o.Backups()
+	if len(backups) != 1 {
+		t.Fatalf("Backups() = %v, want one backup", backups)
+	}
+
+	if got := readTestFile(t, backups[0]); got != legacy {
+		t.Errorf("backup:\n%s\nwant the original file", got)
+	}
+}
+
+func TestLoadRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeTestFile(t, path, 0600, "version: 99\njumps: []\n")
+
+	_, err := LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), "please upgrade port-jump") {
+		t.Errorf("LoadFile() error = %v, want an upgrade hint", err)
+	}
+}
+
+func TestSaveFollowsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "port-jump.yml")
+	path := filepath.Join(dir, "config.yml")
+
+	writeTestFile(t, target, 0600, "version: 4\njumps: []\n")
+	if err := os.Symlink(target, path); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	err = o.Update(func(o *Options) error {
+		jump, err := NewPortJump("ssh", 22, "JBSWY3DPEHPK3PXP", 30, true)
+		if err != nil {
+			return err
+		}
+
+		o.Jumps = append(o.Jumps, jump)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Error("saving replaced the symlink with a regular file")
+	}
+
+	if got := readTestFile(t, target); !strings.Contains(got, "name: ssh") {
+		t.Errorf("symlink target was not updated:\n%s", got)
+	}
+}
+
+func TestLoadMigratesInMemoryWhenReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	legacy := `jumps:
+  - enabled: true
+    dstport: 22
+    interval: 30
+    sharedsecret: JBSWY3DPEHPK3PXP
+`
+	writeTestFile(t, path, 0600, legacy)
+
+	// a directory in place of the lock file cannot be opened, even by root
+	if err := os.Mkdir(path+".lock", 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if o.MigrateErr() == nil {
+		t.Error("MigrateErr() = nil, want the error rewriting the file")
+	}
+
+	if len(o.Jumps) != 1 || o.Jumps[0].Name == "" {
+		t.Errorf("Jumps = %+v, want one jump named in memory", o.Jumps)
+	}
+
+	if got := readTestFile(t, path); got != legacy {
+		t.Errorf("file changed to:\n%s", got)
+	}
+}
@@ -0,0 +1,138 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Host is a server a client derives ports for. Jumps of different hosts may
+// use the same name or destination port without colliding, as a host's jumps
+// are only ever selected through the host.
+type Host struct {
+	Name    string      `yaml:"name"`
+	Aliases []string    `yaml:"aliases,omitempty"`
+	Address string      `yaml:"address"`
+	Jumps   []*PortJump `yaml:"jumps"`
+}
+
+// Matches reports if host is called name, or has name as an alias
+func (h *Host) Matches(name string) bool {
+	if strings.EqualFold(h.Name, name) {
+		return true
+	}
+
+	for _, alias := range h.Aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Jump returns the host's jump called name, or nil if there is none
+func (h *Host) Jump(name string) *PortJump {
+	for _, jump := range h.Jumps {
+		if jump.Name == name {
+			return jump
+		}
+	}
+
+	return nil
+}
+
+// Select returns every jump of the host matching s
+func (h *Host) Select(s Selector) []*PortJump {
+	return selectJumps(h.Jumps, s)
+}
+
+// SelectOne returns the single jump of the host matching s
+func (h *Host) SelectOne(s Selector) (*PortJump, error) {
+	jump, err := selectOne(h.Jumps, s)
+	if err != nil {
+		return nil, fmt.Errorf("host %s: %v", h.Name, err)
+	}
+
+	return jump, nil
+}
+
+// Host returns the host called name or with name as an alias, or nil if there is none
+func (o *Options) Host(name string) *Host {
+	for _, host := range o.Hosts {
+		if host.Matches(name) {
+			return host
+		}
+	}
+
+	return nil
+}
+
+// HostByAddress returns the first host with address, or nil if there is none
+func (o *Options) HostByAddress(address string) *Host {
+	for _, host := range o.Hosts {
+		if strings.EqualFold(host.Address, address) {
+			return host
+		}
+	}
+
+	return nil
+}
+
+// allJumps returns the jumps served by this host, followed by the jumps of every client host entry
+func (o *Options) allJumps() []*PortJump {
+	jumps := append([]*PortJump{}, o.Jumps...)
+	for _, host := range o.Hosts {
+		jumps = append(jumps, host.Jumps...)
+	}
+
+	return jumps
+}
+
+// validateHosts checks host entries, and the jumps of every host
+func (o *Options) validateHosts() []Problem {
+	var problems []Problem
+
+	// names maps host names and aliases to the host using it
+	names := make(map[string]string)
+
+	for i, host := range o.Hosts {
+		id := host.Name
+		if id == "" {
+			id = fmt.Sprintf("hosts[%d]", i)
+		}
+
+		add := func(field string, format string, a ...interface{}) {
+			problems = append(problems, Problem{
+				Severity: SeverityError,
+				Jump:     id,
+				Field:    field,
+				Message:  fmt.Sprintf(format, a...),
+			})
+		}
+
+		for _, name := range append([]string{host.Name}, host.Aliases...) {
+			if err := ValidateName(name); err != nil {
+				add("name", "%v", err)
+				continue
+			}
+
+			key := strings.ToLower(name)
+			if other, ok := names[key]; ok {
+				add("name", "name %q is also used by host %s", name, other)
+			} else {
+				names[key] = id
+			}
+		}
+
+		if host.Address == "" {
+			add("address", "address cannot be empty")
+		}
+
+		for _, problem := range (&Options{Jumps: host.Jumps}).Validate() {
+			problem.Jump = id + "/" + problem.Jump
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,94 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHostMatches(t *testing.T) {
+	host := &Host{Name: "prod-bastion", Aliases: []string{"bastion", "Jump"}}
+
+	for _, name := range []string{"prod-bastion", "PROD-Bastion", "bastion", "jump", "JUMP"} {
+		if !host.Matches(name) {
+			t.Errorf("Matches(%q) = false, want true", name)
+		}
+	}
+
+	for _, name := range []string{"", "prod", "bastion2"} {
+		if host.Matches(name) {
+			t.Errorf("Matches(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestHostLookup(t *testing.T) {
+	o := &Options{Hosts: []*Host{
+		{Name: "prod", Aliases: []string{"bastion"}, Address: "Bastion.example.com"},
+		{Name: "dev", Address: "10.0.0.2"},
+		{Name: "dev-2", Address: "10.0.0.2"},
+	}}
+
+	if host := o.Host("BASTION"); host == nil || host.Name != "prod" {
+		t.Errorf("Host(BASTION) = %v, want prod", host)
+	}
+
+	if host := o.Host("staging"); host != nil {
+		t.Errorf("Host(staging) = %s, want none", host.Name)
+	}
+
+	if host := o.HostByAddress("bastion.EXAMPLE.com"); host == nil || host.Name != "prod" {
+		t.Errorf("HostByAddress(bastion.EXAMPLE.com) = %v, want prod", host)
+	}
+
+	// the first host with an address wins
+	if host := o.HostByAddress("10.0.0.2"); host == nil || host.Name != "dev" {
+		t.Errorf("HostByAddress(10.0.0.2) = %v, want dev", host)
+	}
+
+	if host := o.HostByAddress("10.0.0.3"); host != nil {
+		t.Errorf("HostByAddress(10.0.0.3) = %s, want none", host.Name)
+	}
+}
+
+func TestHostSelectOne(t *testing.T) {
+	o := &Options{
+		Jumps: []*PortJump{{Name: "ssh", Enabled: true, DstPort: 22}},
+		Hosts: []*Host{
+			{Name: "prod", Jumps: []*PortJump{
+				{Name: "ssh", Enabled: true, DstPort: 22},
+				{Name: "web", Enabled: true, DstPort: 443, Tags: []string{"http"}},
+				{Name: "web-alt", Enabled: true, DstPort: 443},
+			}},
+			{Name: "dev", Jumps: []*PortJump{
+				{Name: "ssh", Enabled: true, DstPort: 2222},
+			}},
+		},
+	}
+
+	prod, dev := o.Host("prod"), o.Host("dev")
+
+	// selection is scoped to the host, jumps of other hosts never match
+	if jump, err := prod.SelectOne(Selector{Port: 22}); err != nil || jump != prod.Jumps[0] {
+		t.Errorf("prod SelectOne(port 22) = %v, %v, want the ssh jump of prod", jump, err)
+	}
+
+	if jump, err := dev.SelectOne(Selector{Name: "ssh"}); err != nil || jump.DstPort != 2222 {
+		t.Errorf("dev SelectOne(name ssh) = %v, %v, want the ssh jump of dev", jump, err)
+	}
+
+	if _, err := dev.SelectOne(Selector{Port: 22}); err == nil || !strings.Contains(err.Error(), "host dev: no jump matching port 22 found") {
+		t.Errorf("dev SelectOne(port 22) error = %v, want no match on host dev", err)
+	}
+
+	if _, err := prod.SelectOne(Selector{Port: 443}); err == nil || !strings.Contains(err.Error(), "host prod: more than one jump matches port 443") {
+		t.Errorf("prod SelectOne(port 443) error = %v, want an ambiguous match on host prod", err)
+	}
+
+	if jump, err := prod.SelectOne(Selector{Tag: "http", Port: 443}); err != nil || jump.Name != "web" {
+		t.Errorf("prod SelectOne(tag http, port 443) = %v, %v, want web", jump, err)
+	}
+
+	if got := len(prod.Select(Selector{Port: 443})); got != 2 {
+		t.Errorf("prod Select(port 443) returned %d jumps, want 2", got)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package options
+
+// lockFile is a no-op on systems without flock support
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -5,34 +5,78 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"reflect"
 
-	"github.com/spf13/viper"
+	"port-jump/pkg/hotp"
 )
 
 const configDir = ".config/port-jump"
 const configFile = "config.yml"
 
-type Options struct {
-	LogDebug bool
+// dropInDir is the directory, relative to the config file, whose files are merged in
+const dropInDir = "conf.d"
+
+// SystemConfigPath is the default configuration file used by the jump daemon
+const SystemConfigPath = "/etc/port-jump/config.yml"
+
+// ConfigEnv is the environment variable that can be used to set the config file path
+const ConfigEnv = "PORT_JUMP_CONFIG"
+
+// DefaultInterval is the rate, in seconds, ports change unless configured otherwise
+const DefaultInterval = 30
 
-	Jumps []*PortJump `mapstructure:"jumps"`
+type Options struct {
+	LogDebug bool `yaml:"-"`
+
+	Version int           `yaml:"version"`
+	Vault   *VaultOptions `yaml:"vault,omitempty"`
+	Jumps   []*PortJump   `yaml:"jumps"`
+	Hosts   []*Host       `yaml:"hosts,omitempty"`
+
+	// path is an explicitly configured config file path
+	path string
+	// dropIns are the conf.d files that were merged in on Load
+	dropIns []string
+	// dropInsLoaded are the conf.d files as loaded, encoded the way they are saved
+	dropInsLoaded map[string][]byte
+	// env is set when jumps are read from environment variables
+	env bool
+	// outdated are the files read on Load that use an older schema version
+	outdated []outdatedFile
+	// backups are the backups written when migrating outdated files
+	backups []string
+	// migrateErr is why outdated files could not be rewritten on Load
+	migrateErr error
 }
 
 type PortJump struct {
-	Enabled      bool   `mapstructure:"enabled"`
-	DstPort      int    `mapstructure:"dstport"`
-	Interval     int64  `mapstructure:"interval"`
-	SharedSecret string `mapstructure:"sharedsecret"`
+	Name         string   `yaml:"name"`
+	Description  string   `yaml:"description,omitempty"`
+	Tags         []string `yaml:"tags,omitempty"`
+	Interface    string   `yaml:"interface,omitempty"`
+	Enabled      bool     `yaml:"enabled"`
+	DstPort      int      `yaml:"dstport"`
+	Interval     int64    `yaml:"interval"`
+	SharedSecret string   `yaml:"sharedsecret"`
+
+	// resolved is the shared secret after resolving references such as vault:
+	resolved string
+	// resolveErr is set when the shared secret reference could not be resolved
+	resolveErr error
+	// source is the conf.d file this jump was loaded from, or empty for the main config file
+	source string
 }
 
 // NewOptions returns fresh Options
 func NewOptions() *Options {
-	return &Options{}
+	return &Options{Version: CurrentVersion}
 }
 
 // NewPortJump returns a new port jumping configuration
-func NewPortJump(dst int, secret string, interval int64, enabled bool) (*PortJump, error) {
+func NewPortJump(name string, dst int, secret string, interval int64, enabled bool) (*PortJump, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
 	if dst == 0 {
 		return nil, errors.New("dst cant be 0")
 	}
@@ -46,6 +90,7 @@ func NewPortJump(dst int, secret string, interval int64, enabled bool) (*PortJum
 	}
 
 	return &PortJump{
+		Name:         name,
 		Enabled:      enabled,
 		DstPort:      dst,
 		SharedSecret: secret,
@@ -53,85 +98,86 @@ func NewPortJump(dst int, secret string, interval int64, enabled bool) (*PortJum
 	}, nil
 }
 
-// configPath returns the path where configuration should live.
-// if the config file does not exist, it will be created.
-func (o *Options) configPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %v", err)
+// Secret returns the shared secret to use for port generation. If the configured
+// shared secret is a reference, the resolved value is returned.
+func (p *PortJump) Secret() string {
+	if p.resolved != "" {
+		return p.resolved
 	}
 
-	p := filepath.Join(home, configDir, configFile)
-
-	if _, err := os.Stat(p); os.IsNotExist(err) {
-		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
-			return "", fmt.Errorf("failed to create config directory: %v", err)
-		}
-	}
+	return p.SharedSecret
+}
 
-	return p, nil
+// ResolveErr returns why the shared secret reference could not be resolved,
+// or nil if it was resolved or is not a reference.
+func (p *PortJump) ResolveErr() error {
+	return p.resolveErr
 }
 
-// Load loads configuration from the config file
-func (o *Options) Load() error {
-	configPath, err := o.configPath()
-	if err != nil {
-		return err
+// Totp returns the port generator for the jump. It fails if the shared
+// secret is a reference that could not be resolved.
+func (p *PortJump) Totp() (*hotp.Hotp, error) {
+	if p.resolveErr != nil {
+		return nil, p.resolveErr
 	}
 
-	viper.SetConfigFile(configPath)
+	return hotp.NewTotp(p.Secret(), p.Interval)
+}
 
-	// Check if the file exists before trying to read it
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		// If the config file does not exist, just return without an error
-		// as this is expected on the first run.
-		return nil
+// SetSharedSecret sets the configured shared secret. A previously resolved
+// reference no longer applies, and is dropped.
+func (p *PortJump) SetSharedSecret(secret string) {
+	if secret != p.SharedSecret {
+		p.resolved = ""
+		p.resolveErr = nil
 	}
 
-	viper.SetConfigFile(configPath)
+	p.SharedSecret = secret
+}
 
-	if err := viper.ReadInConfig(); err != nil {
-		// Handle other potential errors reading the config file
-		return fmt.Errorf("failed to read config file: %v", err)
+// HasTag reports if the jump is tagged with tag
+func (p *PortJump) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
 	}
 
-	if err := viper.Unmarshal(o); err != nil {
-		return fmt.Errorf("failed to unmarshal config into options struct: %v", err)
-	}
+	return false
+}
 
-	return nil
+// Source returns the conf.d file a jump was loaded from. An empty string
+// means the jump lives in the main configuration file.
+func (p *PortJump) Source() string {
+	return p.source
 }
 
-// Save saves configuration to the config file
-func (o *Options) Save() error {
-	configPath, err := o.configPath()
+// DefaultConfigPath returns the per-user configuration file path
+func DefaultConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to get home directory: %v", err)
 	}
 
-	// Use reflection to loop through the struct fields and set them in Viper
-	val := reflect.ValueOf(o).Elem()
-	typ := val.Type()
-
-	for i := 0; i < val.NumField(); i++ {
-		field := typ.Field(i)
-		fieldName := field.Tag.Get("mapstructure")
-		fieldValue := val.Field(i).Interface()
+	return filepath.Join(home, configDir, configFile), nil
+}
 
-		if fieldName != "" {
-			viper.Set(fieldName, fieldValue)
-		}
-	}
+// SetConfigPath sets the configuration file to use, overriding the defaults
+func (o *Options) SetConfigPath(p string) {
+	o.path = p
+}
 
-	// Write the options to the YAML file
-	if err := viper.WriteConfigAs(configPath); err != nil {
-		return fmt.Errorf("failed to write config file: %v", err)
+// ConfigPath returns the configuration file in use. In order of preference
+// this is an explicitly set path, the PORT_JUMP_CONFIG environment variable
+// and finally the per-user default.
+func (o *Options) ConfigPath() (string, error) {
+	if o.path != "" {
+		return o.path, nil
 	}
 
-	// Ensure file permissions are set after writing the file
-	if err := os.Chmod(configPath, 0600); err != nil {
-		return fmt.Errorf("failed to set file permissions: %v", err)
+	if p := os.Getenv(ConfigEnv); p != "" {
+		return p, nil
 	}
 
-	return nil
+	return DefaultConfigPath()
 }
@@ -0,0 +1,22 @@
+package options
+
+import "testing"
+
+func TestSetSharedSecret(t *testing.T) {
+	jump := &PortJump{SharedSecret: "vault:secret/port-jump/ssh", resolved: "JBSWY3DPEHPK3PXP"}
+
+	clone := jump.Clone()
+	clone.SetSharedSecret("vault:secret/port-jump/ssh")
+	if got := clone.Secret(); got != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Secret() after setting the same reference = %q, want the resolved value", got)
+	}
+
+	clone.SetSharedSecret("vault:secret/port-jump/web")
+	if got := clone.Secret(); got != "vault:secret/port-jump/web" {
+		t.Errorf("Secret() after changing the reference = %q, want the unresolved reference", got)
+	}
+
+	if got := jump.Secret(); got != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Secret() of the original jump = %q, want it unchanged", got)
+	}
+}
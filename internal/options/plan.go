@@ -0,0 +1,195 @@
+package options
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"port-jump/internal/secrets"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Action is the kind of change a plan makes to a jump
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// PlanItem is a single change needed to reconcile jumps with a desired state
+type PlanItem struct {
+	Action  Action   `json:"action"`
+	Name    string   `json:"name"`
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// DesiredJump is a jump in a desired-state document
+type DesiredJump struct {
+	*PortJump
+
+	// SetsEnabled is set if the document sets enabled for the jump
+	SetsEnabled bool
+}
+
+// ReadDesiredJumps reads a desired-state document from path, or stdin if
+// path is "-". The document uses the configuration file format, and every
+// jump in it needs a unique name.
+func ReadDesiredJumps(path string) ([]*DesiredJump, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read desired state: %v", err)
+	}
+
+	return parseDesiredJumps(path, data)
+}
+
+// parseDesiredJumps parses the desired-state document data, read from path
+func parseDesiredJumps(path string, data []byte) ([]*DesiredJump, error) {
+	if _, err := schemaVersion(path, data); err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		Jumps []yaml.Node `yaml:"jumps"`
+	}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse desired state: %v", err)
+	}
+
+	var desired []*DesiredJump
+	names := make(map[string]bool)
+	for i, entry := range document.Jumps {
+		// null entries would decode to nil jumps
+		if entry.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("jumps[%d] is not a jump", i)
+		}
+
+		jump := &PortJump{}
+		if err := entry.Decode(jump); err != nil {
+			return nil, fmt.Errorf("failed to parse desired state: jumps[%d]: %v", i, err)
+		}
+
+		// decode again to find jumps that do not set enabled
+		var fields map[string]interface{}
+		if err := entry.Decode(&fields); err != nil {
+			return nil, fmt.Errorf("failed to parse desired state: jumps[%d]: %v", i, err)
+		}
+		_, setsEnabled := fields["enabled"]
+
+		if jump.Name == "" {
+			return nil, fmt.Errorf("jumps[%d] has no name, every desired jump needs one", i)
+		}
+
+		if err := ValidateName(jump.Name); err != nil {
+			return nil, fmt.Errorf("jumps[%d]: %v", i, err)
+		}
+
+		if names[jump.Name] {
+			return nil, fmt.Errorf("jump %s is defined more than once", jump.Name)
+		}
+		names[jump.Name] = true
+
+		if jump.DstPort < 1 || jump.DstPort > 65535 {
+			return nil, fmt.Errorf("jump %s: port %d is not between 1 and 65535", jump.Name, jump.DstPort)
+		}
+
+		if jump.Interval < 1 {
+			return nil, fmt.Errorf("jump %s: interval has to be more than 0", jump.Name)
+		}
+
+		// a blank secret keeps the current one, or gets a generated one
+		if jump.SharedSecret != "" {
+			if err := ValidateSecret(jump.SharedSecret); err != nil {
+				return nil, fmt.Errorf("jump %s: invalid secret: %v", jump.Name, err)
+			}
+		}
+
+		desired = append(desired, &DesiredJump{PortJump: jump, SetsEnabled: setsEnabled})
+	}
+
+	return desired, nil
+}
+
+// Reconcile computes the jumps needed to match desired, matching jumps by
+// name. Jumps missing from desired are removed. Existing jumps keep their
+// shared secret if desired has none, or always if preserveSecrets is set.
+// New jumps without a shared secret get a generated one. Jumps that do not
+// set enabled keep their current state, and new ones are enabled. The
+// resulting jumps and the plan to get there are returned, current is not
+// changed.
+func Reconcile(current []*PortJump, desired []*DesiredJump, preserveSecrets bool) ([]*PortJump, []PlanItem, error) {
+	existing := make(map[string]*PortJump)
+	for _, jump := range current {
+		existing[jump.Name] = jump
+	}
+
+	var (
+		result []*PortJump
+		plan   []PlanItem
+		wanted = make(map[string]bool)
+	)
+
+	for _, want := range desired {
+		wanted[want.Name] = true
+		jump := want.Clone()
+
+		have, ok := existing[want.Name]
+		if !want.SetsEnabled {
+			jump.Enabled = !ok || have.Enabled
+		}
+
+		if !ok {
+			if jump.SharedSecret == "" {
+				secret, err := secrets.GenerateTOTPSecret(16)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to generate a secret for %s: %v", jump.Name, err)
+				}
+				jump.SharedSecret = secret
+			}
+
+			// new jumps are written to the main configuration file
+			jump.source = ""
+			jump.resolved = ""
+
+			result = append(result, jump)
+			plan = append(plan, PlanItem{Action: ActionCreate, Name: jump.Name})
+			continue
+		}
+
+		if jump.SharedSecret == "" || preserveSecrets {
+			jump.SharedSecret = have.SharedSecret
+		}
+
+		// keep the jump in the file it came from
+		jump.source = have.source
+		jump.resolved = ""
+		if jump.SharedSecret == have.SharedSecret {
+			jump.resolved = have.resolved
+		}
+
+		result = append(result, jump)
+		if changes := DiffJump(have, jump); len(changes) > 0 {
+			plan = append(plan, PlanItem{Action: ActionUpdate, Name: jump.Name, Changes: changes})
+		}
+	}
+
+	for _, jump := range current {
+		if !wanted[jump.Name] {
+			plan = append(plan, PlanItem{Action: ActionDelete, Name: jump.Name})
+		}
+	}
+
+	return result, plan, nil
+}
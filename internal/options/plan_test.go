@@ -0,0 +1,258 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	const (
+		secret = "JBSWY3DPEHPK3PXP"
+		other  = "FWX2CC3PLA4ZYGCI"
+	)
+
+	current := func() []*PortJump {
+		return []*PortJump{
+			{Name: "ssh", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: secret},
+			{Name: "team", Enabled: true, DstPort: 2222, Interval: 30, SharedSecret: secret, source: "conf.d/team.yml"},
+			{Name: "web", DstPort: 443, Interval: 30, SharedSecret: secret},
+		}
+	}
+
+	desired := func(jump PortJump, setsEnabled bool) *DesiredJump {
+		return &DesiredJump{PortJump: &jump, SetsEnabled: setsEnabled}
+	}
+
+	tests := []struct {
+		name     string
+		desired  []*DesiredJump
+		preserve bool
+		// plan lists the plan as action name, followed by the changed fields
+		plan []string
+		// check inspects the resulting jumps by name
+		check func(t *testing.T, jumps map[string]*PortJump)
+	}{
+		{
+			name: "create, update and delete",
+			desired: []*DesiredJump{
+				desired(PortJump{Name: "ssh", DstPort: 22, Interval: 60, SharedSecret: secret}, false),
+				desired(PortJump{Name: "team", DstPort: 2222, Interval: 30, SharedSecret: secret}, false),
+				desired(PortJump{Name: "db", DstPort: 5432, Interval: 30, SharedSecret: other}, true),
+			},
+			plan: []string{"update ssh interval", "create db", "delete web"},
+			check: func(t *testing.T, jumps map[string]*PortJump) {
+				if jumps["ssh"].Interval != 60 {
+					t.Errorf("ssh interval = %d, want 60", jumps["ssh"].Interval)
+				}
+				if _, ok := jumps["web"]; ok {
+					t.Errorf("web was kept, want it deleted")
+				}
+			},
+		},
+		{
+			name: "no changes",
+			desired: []*DesiredJump{
+				desired(PortJump{Name: "ssh", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: secret}, true),
+				desired(PortJump{Name: "team", Enabled: true, DstPort: 2222, Interval: 30, SharedSecret: secret}, true),
+				desired(PortJump{Name: "web", DstPort: 443, Interval: 30, SharedSecret: secret}, true),
+			},
+		},
+		{
+			name: "secrets kept when omitted",
+			desired: []*DesiredJump{
+				desired(PortJump{Name: "ssh", DstPort: 22, Interval: 30}, false),
+				desired(PortJump{Name: "team", DstPort: 2222, Interval: 30, SharedSecret: other}, false),
+				desired(PortJump{Name: "web", DstPort: 443, Interval: 30}, false),
+			},
+			plan: []string{"update team sharedsecret"},
+			check: func(t *testing.T, jumps map[string]*PortJump) {
+				if jumps["ssh"].SharedSecret != secret {
+					t.Errorf("ssh secret = %q, want it kept", jumps["ssh"].SharedSecret)
+				}
+				if jumps["team"].SharedSecret != other {
+					t.Errorf("team secret = %q, want it replaced", jumps["team"].SharedSecret)
+				}
+			},
+		},
+		{
+			name: "secrets preserved",
+			desired: []*DesiredJump{
+				desired(PortJump{Name: "ssh", DstPort: 22, Interval: 30, SharedSecret: other}, false),
+				desired(PortJump{Name: "team", DstPort: 2222, Interval: 30, SharedSecret: other}, false),
+				desired(PortJump{Name: "web", DstPort: 443, Interval: 30, SharedSecret: other}, false),
+			},
+			preserve: true,
+			check: func(t *testing.T, jumps map[string]*PortJump) {
+				for name, jump := range jumps {
+					if jump.SharedSecret != secret {
+						t.Errorf("%s secret = %q, want it preserved", name, jump.SharedSecret)
+					}
+				}
+			},
+		},
+		{
+			name: "drop-in source retained",
+			desired: []*DesiredJump{
+				desired(PortJump{Name: "team", DstPort: 2223, Interval: 30}, false),
+				desired(PortJump{Name: "new", DstPort: 8080, Interval: 30, SharedSecret: secret}, false),
+			},
+			plan: []string{"update team dstport", "create new", "delete ssh", "delete web"},
+			check: func(t *testing.T, jumps map[string]*PortJump) {
+				if got := jumps["team"].Source(); got != "conf.d/team.yml" {
+					t.Errorf("team source = %q, want conf.d/team.yml", got)
+				}
+				if got := jumps["new"].Source(); got != "" {
+					t.Errorf("new source = %q, want the main configuration file", got)
+				}
+			},
+		},
+		{
+			name: "secrets generated for new jumps",
+			desired: []*DesiredJump{
+				desired(PortJump{Name: "ssh", DstPort: 22, Interval: 30}, false),
+				desired(PortJump{Name: "team", DstPort: 2222, Interval: 30}, false),
+				desired(PortJump{Name: "web", DstPort: 443, Interval: 30}, false),
+				desired(PortJump{Name: "db", DstPort: 5432, Interval: 30}, false),
+			},
+			plan: []string{"create db"},
+			check: func(t *testing.T, jumps map[string]*PortJump) {
+				if _, err := jumps["db"].Totp(); err != nil {
+					t.Errorf("generated secret %q is not usable: %v", jumps["db"].SharedSecret, err)
+				}
+			},
+		},
+		{
+			name: "enabled kept when omitted",
+			desired: []*DesiredJump{
+				desired(PortJump{Name: "ssh", DstPort: 22, Interval: 30}, false),
+				desired(PortJump{Name: "team", DstPort: 2222, Interval: 30}, true),
+				desired(PortJump{Name: "web", DstPort: 443, Interval: 30}, false),
+				desired(PortJump{Name: "db", DstPort: 5432, Interval: 30}, false),
+			},
+			plan: []string{"update team enabled", "create db"},
+			check: func(t *testing.T, jumps map[string]*PortJump) {
+				if !jumps["ssh"].Enabled || jumps["team"].Enabled || jumps["web"].Enabled || !jumps["db"].Enabled {
+					t.Errorf("enabled ssh=%v team=%v web=%v db=%v, want true, false, false, true",
+						jumps["ssh"].Enabled, jumps["team"].Enabled, jumps["web"].Enabled, jumps["db"].Enabled)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := current()
+			jumps, plan, err := Reconcile(before, tt.desired, tt.preserve)
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			var got []string
+			for _, item := range plan {
+				entry := fmt.Sprintf("%s %s", item.Action, item.Name)
+				for _, change := range item.Changes {
+					entry += " " + change.Field
+				}
+				got = append(got, entry)
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.plan, "\n") {
+				t.Errorf("Reconcile() plan = %q, want %q", got, tt.plan)
+			}
+
+			byName := make(map[string]*PortJump)
+			for _, jump := range jumps {
+				byName[jump.Name] = jump
+			}
+
+			if len(byName) != len(tt.desired) {
+				t.Errorf("Reconcile() returned %d jumps, want %d", len(byName), len(tt.desired))
+			}
+
+			if tt.check != nil {
+				tt.check(t, byName)
+			}
+
+			// the current jumps are left alone
+			for i, jump := range current() {
+				if changes := DiffJump(jump, before[i]); len(changes) > 0 {
+					t.Errorf("Reconcile() changed current jump %s: %v", jump.Name, changes)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDesiredJumps(t *testing.T) {
+	desired, err := parseDesiredJumps("jumps.yml", []byte(`version: 4
+jumps:
+  - name: ssh
+    dstport: 22
+    interval: 30
+  - name: web
+    enabled: false
+    dstport: 443
+    interval: 30
+`))
+	if err != nil {
+		t.Fatalf("parseDesiredJumps() error = %v", err)
+	}
+
+	if len(desired) != 2 || desired[0].SetsEnabled || !desired[1].SetsEnabled {
+		t.Errorf("parseDesiredJumps() = %+v, want only web to set enabled", desired)
+	}
+}
+
+func TestParseDesiredJumpsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{
+			name:     "missing name",
+			document: "jumps:\n  - dstport: 22\n    interval: 30\n",
+			want:     "jumps[0] has no name",
+		},
+		{
+			name:     "duplicate name",
+			document: "jumps:\n  - {name: ssh, dstport: 22, interval: 30}\n  - {name: ssh, dstport: 2222, interval: 30}\n",
+			want:     "jump ssh is defined more than once",
+		},
+		{
+			name:     "invalid port",
+			document: "jumps:\n  - {name: ssh, dstport: 0, interval: 30}\n",
+			want:     "jump ssh: port 0 is not between 1 and 65535",
+		},
+		{
+			name:     "missing interval",
+			document: "jumps:\n  - {name: ssh, dstport: 22}\n",
+			want:     "jump ssh: interval has to be more than 0",
+		},
+		{
+			name:     "invalid secret",
+			document: "jumps:\n  - {name: ssh, dstport: 22, interval: 30, sharedsecret: 'not base32!!'}\n",
+			want:     "jump ssh: invalid secret",
+		},
+		{
+			name:     "null jump",
+			document: "jumps:\n  - null\n",
+			want:     "jumps[0] is not a jump",
+		},
+		{
+			name:     "newer schema",
+			document: "version: 99\njumps: []\n",
+			want:     "uses schema version 99",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDesiredJumps("jumps.yml", []byte(tt.document))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseDesiredJumps() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package options
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"port-jump/internal/vault"
+)
+
+// vaultRefPrefix marks a shared secret as a reference to a Vault KV v2 secret.
+// The full form is vault:<mount>/<path>[#<field>], for example
+// vault:secret/port-jump/ssh#sharedsecret.
+const vaultRefPrefix = "vault:"
+
+// defaultVaultField is the field read from a Vault secret when a reference has none
+const defaultVaultField = "sharedsecret"
+
+type VaultOptions struct {
+	Address      string `yaml:"address,omitempty"`
+	Namespace    string `yaml:"namespace,omitempty"`
+	Auth         string `yaml:"auth,omitempty"`
+	Token        string `yaml:"token,omitempty"`
+	RoleID       string `yaml:"roleid,omitempty"`
+	SecretID     string `yaml:"secretid,omitempty"`
+	AppRoleMount string `yaml:"approlemount,omitempty"`
+}
+
+// vaultRef is a parsed vault: shared secret reference
+type vaultRef struct {
+	mount string
+	path  string
+	field string
+}
+
+// IsVaultRef reports if a shared secret is a Vault reference
+func IsVaultRef(secret string) bool {
+	return strings.HasPrefix(secret, vaultRefPrefix)
+}
+
+// parseVaultRef parses a vault:<mount>/<path>[#<field>] reference
+func parseVaultRef(ref string) (*vaultRef, error) {
+	v := strings.TrimPrefix(ref, vaultRefPrefix)
+
+	field := defaultVaultField
+	if i := strings.LastIndex(v, "#"); i != -1 {
+		field = v[i+1:]
+		v = v[:i]
+	}
+
+	mount, path, ok := strings.Cut(strings.Trim(v, "/"), "/")
+	if !ok || mount == "" || path == "" || field == "" {
+		return nil, fmt.Errorf("invalid vault reference %q, expected vault:<mount>/<path>[#<field>]", ref)
+	}
+
+	return &vaultRef{mount: mount, path: path, field: field}, nil
+}
+
+// vaultClient returns an authenticated Vault client. Values missing from the
+// configuration file are taken from the standard VAULT_* environment variables.
+func (v *VaultOptions) vaultClient(ctx context.Context) (*vault.Client, error) {
+	cfg := VaultOptions{}
+	if v != nil {
+		cfg = *v
+	}
+
+	envFallback(&cfg.Address, "VAULT_ADDR")
+	envFallback(&cfg.Namespace, "VAULT_NAMESPACE")
+	envFallback(&cfg.Token, "VAULT_TOKEN")
+	envFallback(&cfg.RoleID, "VAULT_ROLE_ID")
+	envFallback(&cfg.SecretID, "VAULT_SECRET_ID")
+
+	if cfg.Auth == "" {
+		cfg.Auth = "token"
+		if cfg.RoleID != "" {
+			cfg.Auth = "approle"
+		}
+	}
+
+	client, err := vault.NewClient(cfg.Address, cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Auth {
+	case "token":
+		if cfg.Token == "" {
+			return nil, errors.New("vault token auth selected, but no token is configured")
+		}
+		client.SetToken(cfg.Token)
+	case "approle":
+		if err := client.LoginAppRole(ctx, cfg.AppRoleMount, cfg.RoleID, cfg.SecretID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown vault auth method %q", cfg.Auth)
+	}
+
+	return client, nil
+}
+
+// resolveSecrets resolves shared secret references for every jump, including
+// those of hosts. A reference that cannot be resolved only makes its own jump
+// unusable, see PortJump.ResolveErr.
+func (o *Options) resolveSecrets() {
+	var (
+		client    *vault.Client
+		clientErr error
+	)
+	cache := make(map[string]map[string]interface{})
+	failed := make(map[string]error)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, jump := range o.allJumps() {
+		jump.resolved = ""
+		jump.resolveErr = nil
+
+		if !IsVaultRef(jump.SharedSecret) {
+			continue
+		}
+
+		value, err := func() (string, error) {
+			ref, err := parseVaultRef(jump.SharedSecret)
+			if err != nil {
+				return "", err
+			}
+
+			// only try to prepare the client once, vault is not going to be
+			// reachable for the next jump either
+			if client == nil && clientErr == nil {
+				if client, err = o.Vault.vaultClient(ctx); err != nil {
+					clientErr = fmt.Errorf("failed to prepare vault client: %v", err)
+				}
+			}
+
+			if clientErr != nil {
+				return "", clientErr
+			}
+
+			key := ref.mount + "/" + ref.path
+			if err, ok := failed[key]; ok {
+				return "", err
+			}
+
+			data, ok := cache[key]
+			if !ok {
+				data, err = client.ReadKV2(ctx, ref.mount, ref.path)
+				if err != nil {
+					failed[key] = err
+					return "", err
+				}
+				cache[key] = data
+			}
+
+			value, ok := data[ref.field].(string)
+			if !ok || value == "" {
+				return "", fmt.Errorf("vault secret %s has no string field %q", key, ref.field)
+			}
+
+			return value, nil
+		}()
+		if err != nil {
+			jump.resolveErr = fmt.Errorf("failed to resolve the shared secret of %s: %v", jump.Name, err)
+			continue
+		}
+
+		jump.resolved = value
+	}
+}
+
+// ResolveErrors returns the errors of every jump, including those of hosts,
+// whose shared secret reference could not be resolved
+func (o *Options) ResolveErrors() []error {
+	var errs []error
+	for _, jump := range o.allJumps() {
+		if jump.resolveErr != nil {
+			errs = append(errs, jump.resolveErr)
+		}
+	}
+
+	return errs
+}
+
+// envFallback sets value from the environment variable key if value is empty
+func envFallback(value *string, key string) {
+	if *value == "" {
+		*value = os.Getenv(key)
+	}
+}
@@ -0,0 +1,60 @@
+package options
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadWithVaultDown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	// nothing listens on port 1, so every reference fails to resolve
+	writeTestFile(t, path, 0600, `version: 4
+vault:
+  address: http://127.0.0.1:1
+  token: s.token
+jumps:
+  - name: ssh
+    enabled: true
+    dstport: 22
+    interval: 30
+    sharedsecret: JBSWY3DPEHPK3PXP
+  - name: web
+    enabled: true
+    dstport: 443
+    interval: 30
+    sharedsecret: vault:secret/port-jump/web
+`)
+
+	o, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if _, err := o.Jump("ssh").Totp(); err != nil {
+		t.Errorf("Totp() of a plain secret error = %v", err)
+	}
+
+	_, err = o.Jump("web").Totp()
+	if err == nil || !strings.Contains(err.Error(), "failed to resolve the shared secret of web") {
+		t.Errorf("Totp() of an unresolved reference error = %v, want a resolve error", err)
+	}
+
+	if errs := o.ResolveErrors(); len(errs) != 1 {
+		t.Errorf("ResolveErrors() = %v, want one error", errs)
+	}
+
+	// the broken jump can still be removed
+	err = o.Update(func(o *Options) error {
+		o.Jumps = o.Jumps[:1]
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if !strings.Contains(readTestFile(t, path), "name: ssh") || strings.Contains(readTestFile(t, path), "name: web") {
+		t.Errorf("Update() did not remove web:\n%s", readTestFile(t, path))
+	}
+}
@@ -0,0 +1,134 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the configuration schema version this build reads and writes.
+// Bump it, and add a migration, whenever the configuration format changes.
+const CurrentVersion = 4
+
+// legacyVersion is the version assumed for files without a version key
+const legacyVersion = 1
+
+// migrations upgrade loaded options from a schema version to the next one.
+// the key is the version being migrated from. migrations have to be safe to
+// run on jumps that are already in the newer format, as a config file and
+// its drop-ins may be at different versions.
+var migrations = map[int]func(o *Options) error{
+	// version 1 files have no version key. nothing else changed.
+	1: func(o *Options) error { return nil },
+	// version 3 identifies jumps by a unique name
+	2: func(o *Options) error {
+		o.assignNames()
+		return nil
+	},
+	// version 4 adds client host entries. nothing to migrate.
+	3: func(o *Options) error { return nil },
+}
+
+// outdatedFile is a configuration file read with an older schema version
+type outdatedFile struct {
+	path    string
+	version int
+	data    []byte
+}
+
+// schemaVersion returns the schema version of a raw configuration file
+func schemaVersion(path string, data []byte) (int, error) {
+	var v struct {
+		Version int `yaml:"version"`
+	}
+
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return 0, fmt.Errorf("failed to read schema version of %s: %v", path, err)
+	}
+
+	if v.Version == 0 {
+		return legacyVersion, nil
+	}
+
+	if v.Version > CurrentVersion {
+		return 0, fmt.Errorf("%s uses schema version %d, but this build only supports up to version %d. please upgrade port-jump",
+			path, v.Version, CurrentVersion)
+	}
+
+	return v.Version, nil
+}
+
+// trackVersion records path as outdated if it uses an older schema version
+func (o *Options) trackVersion(path string, data []byte) error {
+	version, err := schemaVersion(path, data)
+	if err != nil {
+		return err
+	}
+
+	if version < CurrentVersion {
+		o.outdated = append(o.outdated, outdatedFile{path: path, version: version, data: data})
+	}
+
+	return nil
+}
+
+// migrate runs the migrations needed to bring outdated files to the current version
+func (o *Options) migrate() error {
+	oldest := CurrentVersion
+	for _, file := range o.outdated {
+		if file.version < oldest {
+			oldest = file.version
+		}
+	}
+
+	for version := oldest; version < CurrentVersion; version++ {
+		migration, ok := migrations[version]
+		if !ok {
+			return fmt.Errorf("no migration from schema version %d", version)
+		}
+
+		if err := migration(o); err != nil {
+			return fmt.Errorf("failed to migrate from schema version %d: %v", version, err)
+		}
+	}
+
+	return nil
+}
+
+// backupOutdated writes a copy of every outdated file before it is rewritten
+func (o *Options) backupOutdated() error {
+	stamp := time.Now().Format("20060102T150405")
+
+	for _, file := range o.outdated {
+		backup := fmt.Sprintf("%s.v%d-%s.bak", file.path, file.version, stamp)
+		if err := WriteFileAtomic(backup, file.data, 0600); err != nil {
+			return fmt.Errorf("failed to backup %s before migrating it: %v", file.path, err)
+		}
+
+		o.backups = append(o.backups, backup)
+	}
+
+	return nil
+}
+
+// Backups returns the backups written when older configuration files were migrated on load
+func (o *Options) Backups() []string {
+	return o.backups
+}
+
+// MigrateErr returns why files using an older schema version could not be
+// rewritten on Load. Their configuration is still used, migrated in memory.
+func (o *Options) MigrateErr() error {
+	return o.migrateErr
+}
+
+// Outdated returns the configuration files that use an older schema version
+func (o *Options) Outdated() []string {
+	files := make([]string, 0, len(o.outdated))
+	for _, file := range o.outdated {
+		files = append(files, file.path)
+	}
+
+	return files
+}
@@ -0,0 +1,191 @@
+package options
+
+import (
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// namePattern limits jump names to characters that are safe to use in
+// command lines, firewall rule metadata and ssh_config host aliases
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// ValidateName checks if name can be used as a jump name
+func ValidateName(name string) error {
+	if name == "" {
+		return errors.New("name cant be empty")
+	}
+
+	if len(name) > 64 {
+		return errors.New("name cant be longer than 64 characters")
+	}
+
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("name %q may only contain letters, digits, '.', '_' and '-'", name)
+	}
+
+	return nil
+}
+
+// ValidateSecret checks if secret can be used as a shared secret. It has to
+// be base32 of at least 16 characters, or a vault reference that is resolved
+// on load.
+func ValidateSecret(secret string) error {
+	if IsVaultRef(secret) {
+		return nil
+	}
+
+	if len(secret) < 16 {
+		return fmt.Errorf("the secret has to be at least 16 characters, not %d", len(secret))
+	}
+
+	if _, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret)); err != nil {
+		return errors.New("the secret has to be base32 encoded")
+	}
+
+	return nil
+}
+
+// DefaultName returns a name for a jump to dst that is not used yet
+func (o *Options) DefaultName(dst int) string {
+	base := fmt.Sprintf("port-%d", dst)
+
+	name := base
+	for i := 2; o.Jump(name) != nil; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return name
+}
+
+// Jump returns the jump called name, or nil if there is none
+func (o *Options) Jump(name string) *PortJump {
+	for _, jump := range o.Jumps {
+		if jump.Name == name {
+			return jump
+		}
+	}
+
+	return nil
+}
+
+// Selector selects jumps by name, tag and/or destination port. Every
+// criteria that is set has to match.
+type Selector struct {
+	Name string
+	Tag  string
+	Port int
+}
+
+// Empty reports if no selection criteria are set
+func (s Selector) Empty() bool {
+	return s.Name == "" && s.Tag == "" && s.Port == 0
+}
+
+// Matches reports if jump matches the selector
+func (s Selector) Matches(jump *PortJump) bool {
+	if s.Name != "" && jump.Name != s.Name {
+		return false
+	}
+
+	if s.Tag != "" && !jump.HasTag(s.Tag) {
+		return false
+	}
+
+	if s.Port != 0 && jump.DstPort != s.Port {
+		return false
+	}
+
+	return true
+}
+
+// String returns a human readable description of the selector
+func (s Selector) String() string {
+	var parts []string
+	if s.Name != "" {
+		parts = append(parts, "name "+s.Name)
+	}
+	if s.Tag != "" {
+		parts = append(parts, "tag "+s.Tag)
+	}
+	if s.Port != 0 {
+		parts = append(parts, fmt.Sprintf("port %d", s.Port))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Select returns every jump matching s
+func (o *Options) Select(s Selector) []*PortJump {
+	return selectJumps(o.Jumps, s)
+}
+
+// SelectOne returns the single jump matching s. When more than one jump
+// matches, the single enabled one is returned. It is an error if no jump,
+// or more than one enabled jump matches.
+func (o *Options) SelectOne(s Selector) (*PortJump, error) {
+	return selectOne(o.Jumps, s)
+}
+
+// selectJumps returns every jump in jumps matching s
+func selectJumps(jumps []*PortJump, s Selector) []*PortJump {
+	var selected []*PortJump
+	for _, jump := range jumps {
+		if s.Matches(jump) {
+			selected = append(selected, jump)
+		}
+	}
+
+	return selected
+}
+
+// selectOne returns the single jump in jumps matching s
+func selectOne(jumps []*PortJump, s Selector) (*PortJump, error) {
+	if s.Empty() {
+		return nil, errors.New("select a jump using a name, tag or port")
+	}
+
+	jumps = selectJumps(jumps, s)
+
+	switch len(jumps) {
+	case 0:
+		return nil, fmt.Errorf("no jump matching %s found", s)
+	case 1:
+		return jumps[0], nil
+	}
+
+	// disabled jumps, such as the generated example, should not get in the
+	// way of the enabled jump they share a port with
+	var enabled []*PortJump
+	for _, jump := range jumps {
+		if jump.Enabled {
+			enabled = append(enabled, jump)
+		}
+	}
+
+	switch len(enabled) {
+	case 0:
+	case 1:
+		return enabled[0], nil
+	default:
+		jumps = enabled
+	}
+
+	names := make([]string, 0, len(jumps))
+	for _, jump := range jumps {
+		names = append(names, jump.Name)
+	}
+
+	return nil, fmt.Errorf("more than one jump matches %s (%s), select one by name", s, strings.Join(names, ", "))
+}
+
+// assignNames names jumps that have no name yet, using DefaultName
+func (o *Options) assignNames() {
+	for _, jump := range o.Jumps {
+		if jump.Name == "" {
+			jump.Name = o.DefaultName(jump.DstPort)
+		}
+	}
+}
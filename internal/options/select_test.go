@@ -0,0 +1,53 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectOne(t *testing.T) {
+	o := &Options{Jumps: []*PortJump{
+		{Name: "ssh", DstPort: 22, Tags: []string{"admin"}},
+		{Name: "port-22", Enabled: true, DstPort: 22},
+		{Name: "ops", Enabled: true, DstPort: 2222, Tags: []string{"admin"}},
+		{Name: "dev", Enabled: true, DstPort: 2222},
+		{Name: "web", DstPort: 443},
+		{Name: "web-2", DstPort: 443},
+	}}
+
+	tests := []struct {
+		name     string
+		selector Selector
+		want     string
+		wantErr  string
+	}{
+		{name: "name", selector: Selector{Name: "ssh"}, want: "ssh"},
+		{name: "single enabled jump on a port", selector: Selector{Port: 22}, want: "port-22"},
+		{name: "tag and port", selector: Selector{Tag: "admin", Port: 2222}, want: "ops"},
+		{name: "no criteria", wantErr: "select a jump using a name, tag or port"},
+		{name: "no match", selector: Selector{Port: 80}, wantErr: "no jump matching port 80 found"},
+		{name: "several enabled jumps", selector: Selector{Port: 2222}, wantErr: "more than one jump matches port 2222 (ops, dev)"},
+		{name: "several disabled jumps", selector: Selector{Port: 443}, wantErr: "more than one jump matches port 443 (web, web-2)"},
+		{name: "tag across enabled and disabled", selector: Selector{Tag: "admin"}, want: "ops"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jump, err := o.SelectOne(tt.selector)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("SelectOne() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("SelectOne() error = %v", err)
+			}
+
+			if jump.Name != tt.want {
+				t.Errorf("SelectOne() = %s, want %s", jump.Name, tt.want)
+			}
+		})
+	}
+}
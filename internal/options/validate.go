@@ -0,0 +1,293 @@
+package options
+
+import (
+	"encoding/base32"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"port-jump/pkg/hotp"
+)
+
+// Severity is the severity of a validation problem
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// maxInterval is the longest interval, in seconds, that is considered sensible
+const maxInterval = 300
+
+// Problem is a single issue found while validating configuration
+type Problem struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file,omitempty"`
+	Jump     string   `json:"jump,omitempty"`
+	Field    string   `json:"field,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// String returns a human readable description of the problem
+func (p Problem) String() string {
+	var where []string
+	if p.Jump != "" {
+		where = append(where, p.Jump)
+	}
+	if p.Field != "" {
+		where = append(where, p.Field)
+	}
+
+	if len(where) == 0 {
+		return p.Message
+	}
+
+	return fmt.Sprintf("%s: %s", strings.Join(where, "."), p.Message)
+}
+
+// HasErrors reports if any of the problems are errors
+func HasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateFile loads the configuration file at path and validates it,
+// including the permissions of the file and its drop-ins. Vault references
+// are checked for their syntax, but not resolved.
+func ValidateFile(path string) []Problem {
+	if _, err := os.Stat(path); err != nil {
+		return []Problem{{Severity: SeverityError, File: path, Message: err.Error()}}
+	}
+
+	// read without persisting migrations, validation should not change files.
+	// references are not resolved either, only their syntax is checked.
+	o := NewOptions()
+	if err := o.read(path); err != nil {
+		return []Problem{{Severity: SeverityError, File: path, Message: err.Error()}}
+	}
+
+	var problems []Problem
+	for _, file := range o.outdated {
+		problems = append(problems, Problem{
+			Severity: SeverityWarning,
+			File:     file.path,
+			Message:  fmt.Sprintf("uses schema version %d and will be migrated to version %d when loaded", file.version, CurrentVersion),
+		})
+	}
+
+	for _, file := range append([]string{path}, o.dropIns...) {
+		problems = append(problems, checkPermissions(file)...)
+	}
+
+	for _, problem := range o.Validate() {
+		if problem.File == "" {
+			problem.File = path
+		}
+		problems = append(problems, problem)
+	}
+
+	return problems
+}
+
+// Validate checks every jump for problems that would prevent it from working
+func (o *Options) Validate() []Problem {
+	var problems []Problem
+
+	// names maps a jump name to the first jump using it
+	names := make(map[string]string)
+	// destinations tracks the enabled jumps using a destination port
+	destinations := make(map[int][]destination)
+	// ports maps an enabled destination port to the first jump using it
+	ports := make(map[int]string)
+	// derived tracks the ports enabled jumps derive now and in the next window
+	derived := make(map[string]map[int]string)
+	// derivedBy tracks the ports derived per jump index and window
+	derivedBy := make(map[int]map[string]int)
+
+	for i, jump := range o.Jumps {
+		id := jumpID(i, jump)
+		add := func(severity Severity, field string, format string, a ...interface{}) {
+			problems = append(problems, Problem{
+				Severity: severity,
+				File:     jump.source,
+				Jump:     id,
+				Field:    field,
+				Message:  fmt.Sprintf(format, a...),
+			})
+		}
+
+		if err := ValidateName(jump.Name); err != nil {
+			add(SeverityError, "name", "%v", err)
+		} else if other, ok := names[jump.Name]; ok {
+			add(SeverityError, "name", "name %q is also used by %s", jump.Name, other)
+		} else {
+			names[jump.Name] = fmt.Sprintf("jumps[%d]", i)
+		}
+
+		if jump.DstPort < 1 || jump.DstPort > 65535 {
+			add(SeverityError, "dstport", "port %d is not between 1 and 65535", jump.DstPort)
+		}
+
+		if jump.Interval < 1 {
+			add(SeverityError, "interval", "interval %d has to be more than 0", jump.Interval)
+		} else if jump.Interval > maxInterval {
+			add(SeverityWarning, "interval", "interval %d is more than %d seconds", jump.Interval, maxInterval)
+		}
+
+		secretOK := true
+		if IsVaultRef(jump.Secret()) {
+			// unresolved references cannot derive ports
+			if _, err := parseVaultRef(jump.Secret()); err != nil {
+				add(SeverityError, "sharedsecret", "%v", err)
+			}
+			secretOK = false
+		} else if jump.Secret() == "" {
+			add(SeverityError, "sharedsecret", "secret cannot be empty")
+			secretOK = false
+		} else if key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(jump.Secret())); err != nil {
+			add(SeverityError, "sharedsecret", "secret is not valid unpadded base32: %v", err)
+			secretOK = false
+		} else if len(key) < 10 {
+			add(SeverityWarning, "sharedsecret", "secret is only %d bytes long, consider at least 10", len(key))
+		}
+
+		if !jump.Enabled {
+			continue
+		}
+
+		// jumps to the same port are fine with separate secrets, for example
+		// one per client group, or when bound to different interfaces
+		for _, other := range destinations[jump.DstPort] {
+			switch {
+			case other.iface == jump.Interface && other.secret == jump.Secret():
+				add(SeverityError, "dstport", "destination port %d is also used by %s with the same secret", jump.DstPort, other.id)
+			case other.iface == jump.Interface:
+				add(SeverityWarning, "dstport", "destination port %d is also used by %s, with a separate secret", jump.DstPort, other.id)
+			case other.iface == "" || jump.Interface == "":
+				add(SeverityWarning, "dstport", "destination port %d on %s overlaps with %s on %s",
+					jump.DstPort, interfaceName(jump.Interface), other.id, interfaceName(other.iface))
+			}
+		}
+		destinations[jump.DstPort] = append(destinations[jump.DstPort], destination{id: id, iface: jump.Interface, secret: jump.Secret()})
+
+		if _, ok := ports[jump.DstPort]; !ok {
+			ports[jump.DstPort] = id
+		}
+
+		if !secretOK || jump.Interval < 1 {
+			continue
+		}
+
+		gen, err := hotp.NewTotp(jump.Secret(), jump.Interval)
+		if err != nil {
+			add(SeverityError, "sharedsecret", "failed to get port generator: %v", err)
+			continue
+		}
+
+		now := time.Now()
+		windows := map[string]time.Time{
+			"current": now,
+			"next":    now.Add(time.Duration(jump.Interval) * time.Second),
+		}
+
+		for _, window := range []string{"current", "next"} {
+			port, err := gen.GenerateTCPPortAt(windows[window])
+			if err != nil {
+				add(SeverityError, "sharedsecret", "failed to generate a port: %v", err)
+				break
+			}
+
+			if derived[window] == nil {
+				derived[window] = make(map[int]string)
+			}
+
+			if derivedBy[i] == nil {
+				derivedBy[i] = make(map[string]int)
+			}
+			derivedBy[i][window] = port
+
+			if other, ok := derived[window][port]; ok {
+				add(SeverityWarning, "", "derives port %d in the %s window, colliding with %s", port, window, other)
+			} else {
+				derived[window][port] = id
+			}
+		}
+	}
+
+	// derived ports that land on a configured destination port would shadow that service
+	for i, jump := range o.Jumps {
+		for _, window := range []string{"current", "next"} {
+			port, ok := derivedBy[i][window]
+			if !ok {
+				continue
+			}
+
+			if other, ok := ports[port]; ok {
+				problems = append(problems, Problem{
+					Severity: SeverityWarning,
+					File:     jump.source,
+					Jump:     jumpID(i, jump),
+					Message:  fmt.Sprintf("derives port %d in the %s window, which is the destination of %s", port, window, other),
+				})
+			}
+		}
+	}
+
+	return append(problems, o.validateHosts()...)
+}
+
+// destination is an enabled jump using a destination port
+type destination struct {
+	id     string
+	iface  string
+	secret string
+}
+
+// interfaceName describes the interface a jump is bound to
+func interfaceName(iface string) string {
+	if iface == "" {
+		return "all interfaces"
+	}
+
+	return "interface " + iface
+}
+
+// jumpID returns the identifier used for a jump in problems
+func jumpID(index int, jump *PortJump) string {
+	if jump.Name != "" {
+		return jump.Name
+	}
+
+	return fmt.Sprintf("jumps[%d]", index)
+}
+
+// checkPermissions reports configuration files accessible by others, as
+// they may contain shared secrets
+func checkPermissions(path string) []Problem {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return []Problem{{Severity: SeverityError, File: path, Message: err.Error()}}
+	}
+
+	if mode := info.Mode().Perm(); mode&0077 != 0 {
+		return []Problem{{
+			Severity: SeverityError,
+			File:     path,
+			Message:  fmt.Sprintf("file is accessible by group or others (mode %#o), expected 0600", mode),
+		}}
+	}
+
+	return nil
+}
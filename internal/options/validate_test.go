@@ -0,0 +1,178 @@
+package options
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateFileDoesNotResolveVaultRefs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	// the address is unreachable, resolving references would fail
+	writeTestFile(t, path, 0600, `version: 4
+vault:
+  address: http://127.0.0.1:1
+  token: s.token
+jumps:
+  - name: ssh
+    enabled: true
+    dstport: 22
+    interval: 30
+    sharedsecret: vault:secret/port-jump/ssh#sharedsecret
+  - name: web
+    enabled: true
+    dstport: 443
+    interval: 30
+    sharedsecret: vault:secret
+`)
+
+	problems := ValidateFile(path)
+
+	var errors []string
+	for _, problem := range problems {
+		if problem.Severity == SeverityError {
+			errors = append(errors, problem.String())
+		}
+	}
+
+	if len(errors) != 1 || !strings.HasPrefix(errors[0], "web.sharedsecret: invalid vault reference") {
+		t.Errorf("ValidateFile() errors = %q, want only the invalid reference of web", errors)
+	}
+}
+
+func TestValidateSharedDestinations(t *testing.T) {
+	tests := []struct {
+		name  string
+		jumps []*PortJump
+		want  []string
+	}{
+		{
+			name: "separate secrets",
+			jumps: []*PortJump{
+				{Name: "ops", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"},
+				{Name: "dev", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: "FWX2CC3PLA4ZYGCI"},
+			},
+			want: []string{"warning dev.dstport: destination port 22 is also used by ops, with a separate secret"},
+		},
+		{
+			name: "same secret",
+			jumps: []*PortJump{
+				{Name: "ops", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"},
+				{Name: "dev", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"},
+			},
+			want: []string{"error dev.dstport: destination port 22 is also used by ops with the same secret"},
+		},
+		{
+			name: "different interfaces",
+			jumps: []*PortJump{
+				{Name: "lan", Enabled: true, Interface: "eth0", DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"},
+				{Name: "wan", Enabled: true, Interface: "eth1", DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"},
+			},
+		},
+		{
+			name: "all interfaces overlap a named interface",
+			jumps: []*PortJump{
+				{Name: "lan", Enabled: true, Interface: "eth0", DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"},
+				{Name: "any", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: "FWX2CC3PLA4ZYGCI"},
+			},
+			want: []string{"warning any.dstport: destination port 22 on all interfaces overlaps with lan on interface eth0"},
+		},
+		{
+			name: "disabled jumps",
+			jumps: []*PortJump{
+				{Name: "ops", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"},
+				{Name: "dev", DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, problem := range (&Options{Jumps: tt.jumps}).Validate() {
+				if problem.Field == "dstport" {
+					got = append(got, string(problem.Severity)+" "+problem.String())
+				}
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() destination problems = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateHosts(t *testing.T) {
+	jump := func() *PortJump {
+		return &PortJump{Name: "ssh", Enabled: true, DstPort: 22, Interval: 30, SharedSecret: "JBSWY3DPEHPK3PXP"}
+	}
+
+	tests := []struct {
+		name  string
+		hosts []*Host
+		want  []string
+	}{
+		{
+			name: "valid",
+			hosts: []*Host{
+				{Name: "prod", Aliases: []string{"bastion"}, Address: "10.0.0.1", Jumps: []*PortJump{jump()}},
+				{Name: "dev", Address: "10.0.0.2", Jumps: []*PortJump{jump()}},
+			},
+		},
+		{
+			name: "duplicate host name",
+			hosts: []*Host{
+				{Name: "prod", Address: "10.0.0.1"},
+				{Name: "PROD", Address: "10.0.0.2"},
+			},
+			want: []string{`PROD.name: name "PROD" is also used by host prod`},
+		},
+		{
+			name: "alias used by another host",
+			hosts: []*Host{
+				{Name: "prod", Aliases: []string{"bastion"}, Address: "10.0.0.1"},
+				{Name: "dev", Aliases: []string{"Bastion"}, Address: "10.0.0.2"},
+			},
+			want: []string{`dev.name: name "Bastion" is also used by host prod`},
+		},
+		{
+			name: "alias matching another host name",
+			hosts: []*Host{
+				{Name: "prod", Address: "10.0.0.1"},
+				{Name: "dev", Aliases: []string{"prod"}, Address: "10.0.0.2"},
+			},
+			want: []string{`dev.name: name "prod" is also used by host prod`},
+		},
+		{
+			name:  "missing address",
+			hosts: []*Host{{Name: "prod"}},
+			want:  []string{"prod.address: address cannot be empty"},
+		},
+		{
+			name: "invalid jump",
+			hosts: []*Host{
+				{Name: "prod", Address: "10.0.0.1", Jumps: []*PortJump{{Name: "ssh", Enabled: true, DstPort: 22, Interval: 30}}},
+			},
+			want: []string{"prod/ssh.sharedsecret: secret cannot be empty"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the jumps of this host are the same as those of the hosts, without colliding
+			o := &Options{Jumps: []*PortJump{jump()}, Hosts: tt.hosts}
+
+			var got []string
+			for _, problem := range o.Validate() {
+				if problem.Severity == SeverityError {
+					got = append(got, problem.String())
+				}
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() errors = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"port-jump/internal/client"
+)
+
+// socks5Version is the first byte of every SOCKS5 handshake
+const socks5Version = 0x05
+
+// SOCKS5 reply codes
+const (
+	socksSucceeded          = 0x00
+	socksGeneralFailure     = 0x01
+	socksHostUnreachable    = 0x04
+	socksConnectionRefused  = 0x05
+	socksCommandUnsupported = 0x07
+	socksAddressUnsupported = 0x08
+)
+
+// Resolver returns the address and ports to connect to for a requested
+// destination. Destinations that are not jumped are returned unchanged.
+type Resolver func(host string, port int) (address string, ports []int, err error)
+
+// Server is a SOCKS5 and HTTP CONNECT proxy
+type Server struct {
+	// Resolve maps requested destinations to where to connect to
+	Resolve Resolver
+	// Timeout is the timeout connecting to destinations
+	Timeout time.Duration
+	// Report, if set, is called for every proxied request. The port is the
+	// port connected to, and err is set if the request failed.
+	Report func(destination string, address string, port int, err error)
+}
+
+// Serve accepts proxy connections on listener until ctx is done. Errors
+// accepting connections are returned.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		go s.handle(ctx, conn)
+	}
+}
+
+// handle serves a single proxy connection, telling SOCKS5 and HTTP apart by the first byte
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	br := bufio.NewReader(conn)
+	first, err := br.Peek(1)
+	if err != nil {
+		return
+	}
+
+	var remote net.Conn
+	if first[0] == socks5Version {
+		remote, err = s.socks5(ctx, conn, br)
+	} else {
+		remote, err = s.httpConnect(ctx, conn, br)
+	}
+
+	if err != nil {
+		return
+	}
+	defer remote.Close()
+
+	// anything the client sent after the request is still buffered in br
+	client.Bridge(remote, br, conn)
+}
+
+// dial connects to the requested destination, or where it is jumped to
+func (s *Server) dial(ctx context.Context, host string, port int) (net.Conn, error) {
+	destination := net.JoinHostPort(host, strconv.Itoa(port))
+
+	address, ports, err := s.Resolve(host, port)
+	if err != nil {
+		s.report(destination, host, port, err)
+		return nil, err
+	}
+
+	conn, used, err := client.Dial(ctx, address, ports, s.Timeout)
+	s.report(destination, address, used, err)
+
+	return conn, err
+}
+
+// report calls Report, if set
+func (s *Server) report(destination string, address string, port int, err error) {
+	if s.Report != nil {
+		s.Report(destination, address, port, err)
+	}
+}
+
+// socks5 handles a SOCKS5 CONNECT request without authentication
+func (s *Server) socks5(ctx context.Context, conn net.Conn, br *bufio.Reader) (net.Conn, error) {
+	// greeting: version, number of methods, methods
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(br, methods); err != nil {
+		return nil, err
+	}
+
+	noAuth := false
+	for _, method := range methods {
+		if method == 0x00 {
+			noAuth = true
+		}
+	}
+
+	if !noAuth {
+		conn.Write([]byte{socks5Version, 0xff})
+		return nil, errors.New("socks5 client does not support connecting without authentication")
+	}
+
+	if _, err := conn.Write([]byte{socks5Version, 0x00}); err != nil {
+		return nil, err
+	}
+
+	// request: version, command, reserved, address type, address, port
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(br, request); err != nil {
+		return nil, err
+	}
+
+	if request[1] != 0x01 {
+		socks5Reply(conn, socksCommandUnsupported)
+		return nil, fmt.Errorf("unsupported socks5 command %d", request[1])
+	}
+
+	var host string
+	switch request[3] {
+	case 0x01, 0x04:
+		ip := make(net.IP, net.IPv4len)
+		if request[3] == 0x04 {
+			ip = make(net.IP, net.IPv6len)
+		}
+
+		if _, err := io.ReadFull(br, ip); err != nil {
+			return nil, err
+		}
+		host = ip.String()
+	case 0x03:
+		length, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		name := make([]byte, length)
+		if _, err := io.ReadFull(br, name); err != nil {
+			return nil, err
+		}
+		host = string(name)
+	default:
+		socks5Reply(conn, socksAddressUnsupported)
+		return nil, fmt.Errorf("unsupported socks5 address type %d", request[3])
+	}
+
+	portBytes := make([]byte, 2)
+	if _, err := io.ReadFull(br, portBytes); err != nil {
+		return nil, err
+	}
+	port := int(binary.BigEndian.Uint16(portBytes))
+
+	remote, err := s.dial(ctx, host, port)
+	if err != nil {
+		reply := byte(socksGeneralFailure)
+		if client.Refused(err) {
+			reply = socksConnectionRefused
+		} else if _, ok := err.(net.Error); ok {
+			reply = socksHostUnreachable
+		}
+
+		socks5Reply(conn, reply)
+		return nil, err
+	}
+
+	if err := socks5Reply(conn, socksSucceeded); err != nil {
+		remote.Close()
+		return nil, err
+	}
+
+	return remote, nil
+}
+
+// socks5Reply writes a SOCKS5 reply, without a bound address
+func socks5Reply(conn net.Conn, reply byte) error {
+	_, err := conn.Write([]byte{socks5Version, reply, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+	return err
+}
+
+// httpConnect handles an HTTP CONNECT request
+func (s *Server) httpConnect(ctx context.Context, conn net.Conn, br *bufio.Reader) (net.Conn, error) {
+	req, err := http.ReadRequest(br)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Method != http.MethodConnect {
+		httpReply(conn, http.StatusMethodNotAllowed)
+		return nil, fmt.Errorf("unsupported http method %s, only CONNECT is supported", req.Method)
+	}
+
+	host, portString, err := net.SplitHostPort(req.Host)
+	if err != nil {
+		httpReply(conn, http.StatusBadRequest)
+		return nil, err
+	}
+
+	port, err := strconv.Atoi(portString)
+	if err != nil {
+		httpReply(conn, http.StatusBadRequest)
+		return nil, fmt.Errorf("invalid port %q", portString)
+	}
+
+	remote, err := s.dial(ctx, host, port)
+	if err != nil {
+		httpReply(conn, http.StatusBadGateway)
+		return nil, err
+	}
+
+	if err := httpReply(conn, http.StatusOK); err != nil {
+		remote.Close()
+		return nil, err
+	}
+
+	return remote, nil
+}
+
+// httpReply writes an HTTP response without a body
+func httpReply(conn net.Conn, status int) error {
+	_, err := fmt.Fprintf(conn, "HTTP/1.1 %d %s\r\n\r\n", status, http.StatusText(status))
+	return err
+}
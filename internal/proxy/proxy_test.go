@@ -0,0 +1,437 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// request records a destination passed to Resolve and Report
+type request struct {
+	host    string
+	port    int
+	address string
+}
+
+// testServer is a Server that records what it resolves and reports
+type testServer struct {
+	Server
+
+	mu       sync.Mutex
+	resolved []request
+	reported []request
+}
+
+// newTestServer returns a server that rewrites jumped.example and the
+// unroutable 2001:db8::1 to backend, and passes every other destination through
+func newTestServer(backend string) *testServer {
+	backendHost, backendPort := splitHostPort(backend)
+
+	s := &testServer{}
+	s.Timeout = 5 * time.Second
+	s.Resolve = func(host string, port int) (string, []int, error) {
+		s.mu.Lock()
+		s.resolved = append(s.resolved, request{host: host, port: port})
+		s.mu.Unlock()
+
+		switch host {
+		case "jumped.example", "2001:db8::1":
+			return backendHost, []int{backendPort}, nil
+		case "unknown.example":
+			return "", nil, errors.New("unknown destination")
+		}
+
+		return host, []int{port}, nil
+	}
+	s.Report = func(destination string, address string, port int, err error) {
+		host, _ := splitHostPort(destination)
+
+		s.mu.Lock()
+		s.reported = append(s.reported, request{host: host, port: port, address: address})
+		s.mu.Unlock()
+	}
+
+	return s
+}
+
+// connect returns the client end of a connection served by s
+func (s *testServer) connect(t *testing.T) net.Conn {
+	t.Helper()
+
+	conn, server := net.Pipe()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.handle(ctx, server)
+
+	t.Cleanup(func() {
+		cancel()
+		conn.Close()
+	})
+
+	return conn
+}
+
+// lastReport returns the last request reported
+func (s *testServer) lastReport(t *testing.T) request {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.reported) == 0 {
+		t.Fatal("no request was reported")
+	}
+
+	return s.reported[len(s.reported)-1]
+}
+
+// echoServer starts a TCP server echoing back what it reads, returning its address
+func echoServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// refusedAddress returns an address that refuses connections
+func refusedAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := listener.Addr().String()
+	listener.Close()
+
+	return address
+}
+
+func splitHostPort(address string) (string, int) {
+	host, portString, _ := net.SplitHostPort(address)
+	port, _ := strconv.Atoi(portString)
+
+	return host, port
+}
+
+// socks5Request returns a SOCKS5 CONNECT request for an address of type atyp
+func socks5Request(atyp byte, address []byte, port int) []byte {
+	request := []byte{socks5Version, 0x01, 0x00, atyp}
+	if atyp == 0x03 {
+		request = append(request, byte(len(address)))
+	}
+	request = append(request, address...)
+
+	return binary.BigEndian.AppendUint16(request, uint16(port))
+}
+
+// readFull reads n bytes from conn
+func readFull(t *testing.T, conn net.Conn, n int) []byte {
+	t.Helper()
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("failed to read %d bytes: %v", n, err)
+	}
+
+	return buf
+}
+
+// greet sends a SOCKS5 greeting offering methods, returning the reply
+func greet(t *testing.T, conn net.Conn, methods ...byte) []byte {
+	t.Helper()
+
+	greeting := append([]byte{socks5Version, byte(len(methods))}, methods...)
+	if _, err := conn.Write(greeting); err != nil {
+		t.Fatal(err)
+	}
+
+	return readFull(t, conn, 2)
+}
+
+// assertEcho checks data sent over conn comes back
+func assertEcho(t *testing.T, conn io.ReadWriter) {
+	t.Helper()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]byte, 4)
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatalf("failed to read through the proxy: %v", err)
+	}
+
+	if string(got) != "ping" {
+		t.Errorf("read %q through the proxy, want %q", got, "ping")
+	}
+}
+
+func TestSocks5Greeting(t *testing.T) {
+	s := newTestServer(echoServer(t))
+
+	tests := []struct {
+		name    string
+		methods []byte
+		want    []byte
+	}{
+		{name: "no authentication", methods: []byte{0x00}, want: []byte{socks5Version, 0x00}},
+		{name: "no authentication among others", methods: []byte{0x02, 0x00}, want: []byte{socks5Version, 0x00}},
+		{name: "username and password only", methods: []byte{0x02}, want: []byte{socks5Version, 0xff}},
+		{name: "no methods", methods: []byte{}, want: []byte{socks5Version, 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := s.connect(t)
+
+			if got := greet(t, conn, tt.methods...); !bytes.Equal(got, tt.want) {
+				t.Errorf("greeting reply = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSocks5AddressTypes(t *testing.T) {
+	backend := echoServer(t)
+	_, backendPort := splitHostPort(backend)
+
+	tests := []struct {
+		name     string
+		atyp     byte
+		address  []byte
+		port     int
+		wantHost string
+	}{
+		{name: "ipv4", atyp: 0x01, address: net.ParseIP("127.0.0.1").To4(), port: backendPort, wantHost: "127.0.0.1"},
+		{name: "ipv6", atyp: 0x04, address: net.ParseIP("2001:db8::1"), port: 22, wantHost: "2001:db8::1"},
+		{name: "domain", atyp: 0x03, address: []byte("jumped.example"), port: 22, wantHost: "jumped.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(backend)
+			conn := s.connect(t)
+			greet(t, conn, 0x00)
+
+			if _, err := conn.Write(socks5Request(tt.atyp, tt.address, tt.port)); err != nil {
+				t.Fatal(err)
+			}
+
+			if reply := readFull(t, conn, 10); reply[1] != socksSucceeded {
+				t.Fatalf("reply code = %d, want %d", reply[1], socksSucceeded)
+			}
+
+			assertEcho(t, conn)
+
+			s.mu.Lock()
+			resolved := s.resolved[0]
+			s.mu.Unlock()
+
+			if resolved.host != tt.wantHost || resolved.port != tt.port {
+				t.Errorf("resolved %s:%d, want %s:%d", resolved.host, resolved.port, tt.wantHost, tt.port)
+			}
+		})
+	}
+}
+
+func TestSocks5Destinations(t *testing.T) {
+	backend := echoServer(t)
+	backendHost, backendPort := splitHostPort(backend)
+
+	tests := []struct {
+		name    string
+		atyp    byte
+		address []byte
+		port    int
+	}{
+		{name: "rewritten", atyp: 0x03, address: []byte("jumped.example"), port: 22},
+		{name: "passed through", atyp: 0x03, address: []byte(backendHost), port: backendPort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(backend)
+			conn := s.connect(t)
+			greet(t, conn, 0x00)
+
+			if _, err := conn.Write(socks5Request(tt.atyp, tt.address, tt.port)); err != nil {
+				t.Fatal(err)
+			}
+
+			if reply := readFull(t, conn, 10); reply[1] != socksSucceeded {
+				t.Fatalf("reply code = %d, want %d", reply[1], socksSucceeded)
+			}
+
+			assertEcho(t, conn)
+
+			got := s.lastReport(t)
+			want := request{host: string(tt.address), port: backendPort, address: backendHost}
+			if got != want {
+				t.Errorf("reported %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestSocks5Errors(t *testing.T) {
+	refusedHost, refusedPort := splitHostPort(refusedAddress(t))
+
+	tests := []struct {
+		name    string
+		request []byte
+		want    byte
+	}{
+		{
+			name:    "bind command",
+			request: []byte{socks5Version, 0x02, 0x00, 0x01},
+			want:    socksCommandUnsupported,
+		},
+		{
+			name:    "unknown address type",
+			request: []byte{socks5Version, 0x01, 0x00, 0x05},
+			want:    socksAddressUnsupported,
+		},
+		{
+			name:    "connection refused",
+			request: socks5Request(0x01, net.ParseIP(refusedHost).To4(), refusedPort),
+			want:    socksConnectionRefused,
+		},
+		{
+			name:    "unresolvable destination",
+			request: socks5Request(0x03, []byte("unknown.example"), 22),
+			want:    socksGeneralFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(refusedAddress(t))
+			conn := s.connect(t)
+			greet(t, conn, 0x00)
+
+			if _, err := conn.Write(tt.request); err != nil {
+				t.Fatal(err)
+			}
+
+			reply := readFull(t, conn, 10)
+			if reply[0] != socks5Version || reply[1] != tt.want {
+				t.Errorf("reply = %v, want code %d", reply, tt.want)
+			}
+
+			// the connection is closed after an error reply
+			if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+				t.Errorf("read after the reply error = %v, want EOF", err)
+			}
+		})
+	}
+}
+
+func TestHTTPConnect(t *testing.T) {
+	backend := echoServer(t)
+	backendHost, backendPort := splitHostPort(backend)
+
+	tests := []struct {
+		name   string
+		target string
+		want   request
+	}{
+		{name: "rewritten", target: "jumped.example:22", want: request{host: "jumped.example", port: backendPort, address: backendHost}},
+		{name: "passed through", target: backend, want: request{host: backendHost, port: backendPort, address: backendHost}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(backend)
+			conn := s.connect(t)
+
+			if _, err := io.WriteString(conn, "CONNECT "+tt.target+" HTTP/1.1\r\nHost: "+tt.target+"\r\n\r\n"); err != nil {
+				t.Fatal(err)
+			}
+
+			br := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(br, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %s, want 200", resp.Status)
+			}
+
+			assertEcho(t, struct {
+				io.Reader
+				io.Writer
+			}{br, conn})
+
+			if got := s.lastReport(t); got != tt.want {
+				t.Errorf("reported %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPConnectErrors(t *testing.T) {
+	refused := refusedAddress(t)
+
+	tests := []struct {
+		name    string
+		request string
+		want    int
+	}{
+		{name: "not connect", request: "GET http://example.com/ HTTP/1.1\r\nHost: example.com\r\n\r\n", want: http.StatusMethodNotAllowed},
+		{name: "missing port", request: "CONNECT example.com HTTP/1.1\r\nHost: example.com\r\n\r\n", want: http.StatusBadRequest},
+		{name: "connection refused", request: "CONNECT " + refused + " HTTP/1.1\r\nHost: " + refused + "\r\n\r\n", want: http.StatusBadGateway},
+		{name: "unresolvable destination", request: "CONNECT unknown.example:22 HTTP/1.1\r\nHost: unknown.example:22\r\n\r\n", want: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(refused)
+			conn := s.connect(t)
+
+			if _, err := io.WriteString(conn, tt.request); err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %s, want %d", resp.Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package sshconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"port-jump/internal/options"
+)
+
+const (
+	// beginMarker starts the section of an ssh_config file managed by port-jump
+	beginMarker = "# BEGIN port-jump managed section. Changes are overwritten by 'port-jump ssh-config --write'."
+	// endMarker ends the managed section
+	endMarker = "# END port-jump managed section"
+	// closeHosts ends the last generated Host block, so that options following
+	// the managed section apply to every host, as they did before it was added
+	closeHosts = "Host *"
+	// sshPort is the port ssh connects to by default
+	sshPort = 22
+)
+
+// Config describes how Host blocks are generated
+type Config struct {
+	// Command is the port-jump command used in ProxyCommand, including global flags
+	Command string
+	// Ports writes the current port of each jump instead of a ProxyCommand.
+	// The generated configuration then has to be refreshed every interval.
+	Ports bool
+	// Port returns the current port of a jump, used when Ports is set
+	Port func(jump *options.PortJump) (int, error)
+}
+
+// HostKeyAlias returns the known_hosts name for host, which is [host]:port
+// for ports other than the ssh port, the way ssh names them itself
+func HostKeyAlias(host string, port int) string {
+	if port == sshPort {
+		return host
+	}
+
+	return fmt.Sprintf("[%s]:%d", host, port)
+}
+
+// Generate returns Host blocks for every jump of hosts. A host's jump to the
+// ssh port can be reached as the host's name and aliases, and every jump as
+// <host>-<jump>. HostKeyAlias is set to the host name, or [host]:port for
+// other destination ports, so that rotating ports never change which
+// known_hosts entry is used.
+func Generate(hosts []*options.Host, c Config) (string, error) {
+	var b strings.Builder
+
+	for _, host := range hosts {
+		primary := true
+
+		for _, jump := range host.Jumps {
+			patterns := []string{host.Name + "-" + jump.Name}
+			alias := HostKeyAlias(host.Name, jump.DstPort)
+
+			if jump.DstPort == sshPort {
+				if primary {
+					patterns = append(append([]string{host.Name}, host.Aliases...), patterns...)
+					primary = false
+				}
+			}
+
+			if jump.Description != "" {
+				fmt.Fprintf(&b, "# %s\n", jump.Description)
+			}
+
+			fmt.Fprintf(&b, "Host %s\n", strings.Join(patterns, " "))
+			fmt.Fprintf(&b, "  HostName %s\n", host.Address)
+			fmt.Fprintf(&b, "  HostKeyAlias %s\n", alias)
+
+			if c.Ports {
+				port, err := c.Port(jump)
+				if err != nil {
+					return "", fmt.Errorf("failed to generate port for %s/%s: %v", host.Name, jump.Name, err)
+				}
+
+				fmt.Fprintf(&b, "  Port %d\n", port)
+			} else {
+				fmt.Fprintf(&b, "  Port %d\n", jump.DstPort)
+				fmt.Fprintf(&b, "  ProxyCommand %s connect --jump %s %s %%p\n", c.Command, jump.Name, host.Name)
+			}
+
+			b.WriteString("\n")
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// Update replaces the managed section of the ssh_config file at path with
+// section. Without a managed section yet, it is added at the start of the
+// file, as ssh uses the first value it finds for an option. The section ends
+// with Host *, so that options after it are not captured by its last Host
+// block. Everything outside the managed section is kept as is, and a
+// symlinked file, i.e. one kept in a dotfiles repository, stays a symlink.
+func Update(path string, section string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	managed := beginMarker + "\n"
+	if section != "" {
+		managed += strings.TrimSuffix(section, "\n") + "\n\n" + closeHosts + "\n"
+	}
+	managed += endMarker + "\n"
+
+	content := string(data)
+	begin := strings.Index(content, beginMarker)
+	end := strings.Index(content, endMarker)
+
+	switch {
+	case begin == -1 && end == -1:
+		if content != "" {
+			managed += "\n"
+		}
+		content = managed + content
+	case begin == -1 || end < begin:
+		return fmt.Errorf("%s has a damaged port-jump managed section, fix or remove its markers", path)
+	default:
+		rest := content[end+len(endMarker):]
+		rest = strings.TrimPrefix(rest, "\n")
+		content = content[:begin] + managed + rest
+	}
+
+	if content == string(data) {
+		return nil
+	}
+
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return options.WriteFileAtomic(path, []byte(content), mode)
+}
+
+// Command returns the port-jump command line for ProxyCommand, with the
+// configuration file if it is not the default one
+func Command(executable string, configPath string) string {
+	command := quote(executable)
+
+	if defaultPath, err := options.DefaultConfigPath(); err != nil || configPath != defaultPath {
+		command += " --config " + quote(configPath)
+	}
+
+	return command
+}
+
+// quote quotes s for ssh_config if it contains spaces
+func quote(s string) string {
+	if strings.ContainsAny(s, " \t") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
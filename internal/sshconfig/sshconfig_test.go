@@ -0,0 +1,150 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSection = `Host bastion
+  HostName 10.0.0.1
+  Port 22
+`
+
+func TestUpdate(t *testing.T) {
+	managed := beginMarker + "\n" + testSection + "\n" + closeHosts + "\n" + endMarker + "\n"
+
+	tests := []struct {
+		name     string
+		existing string
+		section  string
+		want     string
+	}{
+		{
+			name:    "new file",
+			section: testSection,
+			want:    managed,
+		},
+		{
+			name:     "top-level options stay global",
+			existing: "ServerAliveInterval 30\n\nHost work\n  User me\n",
+			section:  testSection,
+			want:     managed + "\nServerAliveInterval 30\n\nHost work\n  User me\n",
+		},
+		{
+			name:     "replace the managed section",
+			existing: "Host first\n  User me\n" + beginMarker + "\nHost old\n" + endMarker + "\nHost last\n",
+			section:  testSection,
+			want:     "Host first\n  User me\n" + managed + "Host last\n",
+		},
+		{
+			name:     "empty section",
+			existing: beginMarker + "\nHost old\n" + endMarker + "\nHost last\n",
+			want:     beginMarker + "\n" + endMarker + "\nHost last\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".ssh", "config")
+
+			if tt.existing != "" {
+				if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := Update(path, tt.section); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(data) != tt.want {
+				t.Errorf("Update() wrote:\n%s\nwant:\n%s", data, tt.want)
+			}
+
+			// updating again with the same section is a no-op
+			if err := Update(path, tt.section); err != nil {
+				t.Fatalf("second Update() error = %v", err)
+			}
+
+			if again, _ := os.ReadFile(path); string(again) != string(data) {
+				t.Errorf("second Update() changed the file to:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestUpdateDamagedSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+
+	if err := os.WriteFile(path, []byte(endMarker+"\n"+beginMarker+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Update(path, testSection)
+	if err == nil || !strings.Contains(err.Error(), "damaged port-jump managed section") {
+		t.Errorf("Update() error = %v, want a damaged section error", err)
+	}
+}
+
+func TestUpdateFollowsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "ssh_config")
+	path := filepath.Join(dir, ".ssh", "config")
+
+	for _, d := range []string{filepath.Dir(target), filepath.Dir(path)} {
+		if err := os.MkdirAll(d, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(target, []byte("Host work\n  User me\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(target, path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Update(path, testSection); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Error("Update() replaced the symlink with a regular file")
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(data), beginMarker) || !strings.HasSuffix(string(data), "Host work\n  User me\n") {
+		t.Errorf("symlink target was not updated:\n%s", data)
+	}
+}
+
+func TestHostKeyAlias(t *testing.T) {
+	if got := HostKeyAlias("bastion", 22); got != "bastion" {
+		t.Errorf("HostKeyAlias() for the ssh port = %q, want bastion", got)
+	}
+
+	if got := HostKeyAlias("bastion", 2222); got != "[bastion]:2222" {
+		t.Errorf("HostKeyAlias() for another port = %q, want [bastion]:2222", got)
+	}
+}
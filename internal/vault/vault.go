@@ -0,0 +1,157 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client is a minimal HashiCorp Vault client that speaks the HTTP API.
+// ref: https://developer.hashicorp.com/vault/api-docs
+type Client struct {
+	address   string
+	namespace string
+	token     string
+
+	http *http.Client
+}
+
+// NewClient returns a new Vault client for address
+func NewClient(address string, namespace string) (*Client, error) {
+	if address == "" {
+		return nil, errors.New("vault address cannot be empty")
+	}
+
+	return &Client{
+		address:   strings.TrimRight(address, "/"),
+		namespace: namespace,
+		http:      &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// SetToken sets the token used to authenticate requests
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// LoginAppRole authenticates using the AppRole auth method mounted at mount.
+// On success, the returned client token is used for subsequent requests.
+func (c *Client) LoginAppRole(ctx context.Context, mount string, roleID string, secretID string) error {
+	if roleID == "" {
+		return errors.New("approle role id cannot be empty")
+	}
+
+	if mount == "" {
+		mount = "approle"
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"role_id":   roleID,
+		"secret_id": secretID,
+	})
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("auth/%s/login", strings.Trim(mount, "/")), body, &resp); err != nil {
+		return fmt.Errorf("approle login failed: %v", err)
+	}
+
+	if resp.Auth.ClientToken == "" {
+		return errors.New("approle login returned an empty client token")
+	}
+
+	c.token = resp.Auth.ClientToken
+
+	return nil
+}
+
+// ReadKV2 reads the latest version of a secret from a KV version 2 engine
+// mounted at mount, returning the secret's key/value data.
+func (c *Client) ReadKV2(ctx context.Context, mount string, path string) (map[string]interface{}, error) {
+	if c.token == "" {
+		return nil, errors.New("no vault token configured")
+	}
+
+	var resp struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+
+	p := fmt.Sprintf("%s/data/%s", strings.Trim(mount, "/"), strings.Trim(path, "/"))
+	if err := c.do(ctx, http.MethodGet, p, nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", p, err)
+	}
+
+	if resp.Data.Data == nil {
+		return nil, fmt.Errorf("secret %s has no data", p)
+	}
+
+	return resp.Data.Data, nil
+}
+
+// do performs an API request against /v1/path, decoding the JSON response into out
+func (c *Client) do(ctx context.Context, method string, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.address+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var apiErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && len(apiErr.Errors) > 0 {
+			return fmt.Errorf("vault returned %d: %s", res.StatusCode, strings.Join(apiErr.Errors, "; "))
+		}
+
+		return fmt.Errorf("vault returned %d", res.StatusCode)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode vault response: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// standIn returns a local stand-in for a Vault server, with an AppRole login
+// for role/secret, and a KV v2 secret at secret/port-jump/ssh readable with
+// the token.
+func standIn(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+			return
+		}
+
+		w.Write([]byte(`{"auth":{"client_token":"s.approle"}}`))
+	})
+
+	mux.HandleFunc("/v1/secret/data/port-jump/ssh", func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Vault-Token")
+		if token != "s.token" && token != "s.approle" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		if r.Header.Get("X-Vault-Namespace") == "other" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+
+		w.Write([]byte(`{"data":{"data":{"sharedsecret":"JBSWY3DPEHPK3PXP"},"metadata":{"version":3}}}`))
+	})
+
+	mux.HandleFunc("/v1/secret/data/port-jump/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"data":null}}`))
+	})
+
+	mux.HandleFunc("/v1/secret/data/port-jump/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`<html>upstream error</html>`))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server, namespace string) *Client {
+	t.Helper()
+
+	// a trailing slash on the address must not end up in request paths
+	c, err := NewClient(srv.URL+"/", namespace)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	c.http = srv.Client()
+
+	return c
+}
+
+func TestNewClientRequiresAddress(t *testing.T) {
+	if _, err := NewClient("", ""); err == nil {
+		t.Fatal("NewClient(\"\") error = nil, want an error")
+	}
+}
+
+func TestReadKV2WithToken(t *testing.T) {
+	c := newTestClient(t, standIn(t), "")
+	c.SetToken("s.token")
+
+	data, err := c.ReadKV2(context.Background(), "secret", "/port-jump/ssh/")
+	if err != nil {
+		t.Fatalf("ReadKV2() error = %v", err)
+	}
+
+	if got := data["sharedsecret"]; got != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("ReadKV2() sharedsecret = %v, want JBSWY3DPEHPK3PXP", got)
+	}
+}
+
+func TestReadKV2WithAppRole(t *testing.T) {
+	c := newTestClient(t, standIn(t), "")
+
+	if err := c.LoginAppRole(context.Background(), "", "role", "secret"); err != nil {
+		t.Fatalf("LoginAppRole() error = %v", err)
+	}
+
+	if c.token != "s.approle" {
+		t.Errorf("LoginAppRole() token = %q, want s.approle", c.token)
+	}
+
+	if _, err := c.ReadKV2(context.Background(), "secret", "port-jump/ssh"); err != nil {
+		t.Fatalf("ReadKV2() after login error = %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	srv := standIn(t)
+
+	tests := []struct {
+		name string
+		run  func(c *Client) error
+		want string
+	}{
+		{
+			name: "approle without role id",
+			run: func(c *Client) error {
+				return c.LoginAppRole(context.Background(), "approle", "", "secret")
+			},
+			want: "role id cannot be empty",
+		},
+		{
+			name: "approle with a bad secret",
+			run: func(c *Client) error {
+				return c.LoginAppRole(context.Background(), "approle", "role", "wrong")
+			},
+			want: "vault returned 400: invalid role or secret ID",
+		},
+		{
+			name: "read without a token",
+			run: func(c *Client) error {
+				_, err := c.ReadKV2(context.Background(), "secret", "port-jump/ssh")
+				return err
+			},
+			want: "no vault token configured",
+		},
+		{
+			name: "read with a bad token",
+			run: func(c *Client) error {
+				c.SetToken("s.bad")
+				_, err := c.ReadKV2(context.Background(), "secret", "port-jump/ssh")
+				return err
+			},
+			want: "vault returned 403: permission denied",
+		},
+		{
+			name: "read without data",
+			run: func(c *Client) error {
+				c.SetToken("s.token")
+				_, err := c.ReadKV2(context.Background(), "secret", "port-jump/empty")
+				return err
+			},
+			want: "has no data",
+		},
+		{
+			name: "error without a json body",
+			run: func(c *Client) error {
+				c.SetToken("s.token")
+				_, err := c.ReadKV2(context.Background(), "secret", "port-jump/broken")
+				return err
+			},
+			want: "vault returned 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run(newTestClient(t, srv, ""))
+			if err == nil {
+				t.Fatalf("error = nil, want %q", tt.want)
+			}
+
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNamespaceHeader(t *testing.T) {
+	c := newTestClient(t, standIn(t), "other")
+	c.SetToken("s.token")
+
+	_, err := c.ReadKV2(context.Background(), "secret", "port-jump/ssh")
+	if err == nil || !strings.Contains(err.Error(), "vault returned 404") {
+		t.Errorf("ReadKV2() in another namespace error = %v, want a 404", err)
+	}
+}
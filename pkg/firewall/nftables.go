@@ -3,7 +3,9 @@
 package firewall
 
 import (
+	"bytes"
 	"fmt"
+	"net"
 
 	"github.com/google/nftables"
 	"github.com/google/nftables/expr"
@@ -13,10 +15,16 @@ import (
 const (
 	tableName = "port-jump"
 	chainName = "prerouting"
+
+	// clientTableName is the table for rewriting outgoing connections on clients
+	clientTableName = "port-jump-client"
+	clientChainName = "output"
 )
 
 // AddOrUpdateRedirect updates the firewall using NFTables to redirect traffic from, to.
-func AddOrUpdateRedirect(from int, to int) error {
+// Rules are identified by name, so that more than one jump can redirect to the same
+// port. If iface is not empty, only traffic arriving on that interface is redirected.
+func AddOrUpdateRedirect(name string, iface string, from int, to int) error {
 	conn := &nftables.Conn{}
 
 	// Get or create the NAT table
@@ -26,13 +34,13 @@ func AddOrUpdateRedirect(from int, to int) error {
 	}
 
 	// Get or create the chain
-	chain, err := getOrCreateChain(conn, table, chainName)
+	chain, err := getOrCreateChain(conn, table, chainName, nftables.ChainHookPrerouting)
 	if err != nil {
 		return fmt.Errorf("Failed to get or create chain: %v", err)
 	}
 
 	// Find the existing rule and update it if needed
-	err = findAndUpdateRule(conn, table, chain, from, to)
+	err = findAndUpdateRule(conn, table, chain, name, iface, from, to)
 	if err != nil {
 		return fmt.Errorf("Failed to update rule: %vn", err)
 	}
@@ -40,8 +48,118 @@ func AddOrUpdateRedirect(from int, to int) error {
 	return nil
 }
 
+// AddOrUpdateDNAT updates the firewall using NFTables to rewrite outgoing TCP
+// connections to port from on any of addrs, to port to on the same address.
+// Rules are identified by name. Only IPv4 addresses are supported.
+func AddOrUpdateDNAT(name string, addrs []net.IP, from int, to int) error {
+	conn := &nftables.Conn{}
+
+	// Get or create the NAT table
+	table, err := getOrCreateTable(conn, clientTableName, nftables.TableFamilyIPv4)
+	if err != nil {
+		return fmt.Errorf("failed to get or create table: %v", err)
+	}
+
+	// Get or create the chain for locally generated traffic
+	chain, err := getOrCreateChain(conn, table, clientChainName, nftables.ChainHookOutput)
+	if err != nil {
+		return fmt.Errorf("failed to get or create chain: %v", err)
+	}
+
+	rules, err := conn.GetRules(table, chain)
+	if err != nil {
+		return fmt.Errorf("failed to get rules: %v", err)
+	}
+
+	for _, rule := range rules {
+		if ruleMatches(rule, name) {
+			if err := conn.DelRule(rule); err != nil {
+				return fmt.Errorf("failed to delete existing rule: %v", err)
+			}
+		}
+	}
+
+	for _, addr := range addrs {
+		ip := addr.To4()
+		if ip == nil {
+			return fmt.Errorf("address %s is not an IPv4 address", addr)
+		}
+
+		conn.AddRule(&nftables.Rule{
+			Table:    table,
+			Chain:    chain,
+			UserData: []byte(name),
+			Exprs: []expr.Any{
+				// Match TCP packets
+				&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+				&expr.Cmp{
+					Op:       expr.CmpOpEq,
+					Register: 1,
+					Data:     []byte{unix.IPPROTO_TCP},
+				},
+				// Match packets destined for addr
+				&expr.Payload{
+					DestRegister: 1,
+					Base:         expr.PayloadBaseNetworkHeader,
+					Offset:       16, // 16 bytes offset to get the destination address in IPv4 headers
+					Len:          4,
+				},
+				&expr.Cmp{
+					Op:       expr.CmpOpEq,
+					Register: 1,
+					Data:     ip,
+				},
+				// Match packets destined for from
+				&expr.Payload{
+					DestRegister: 1,
+					Base:         expr.PayloadBaseTransportHeader,
+					Offset:       2,
+					Len:          2,
+				},
+				&expr.Cmp{
+					Op:       expr.CmpOpEq,
+					Register: 1,
+					Data:     []byte{byte(from >> 8), byte(from & 0xff)},
+				},
+				// Rewrite the destination to addr and the jumped port
+				&expr.Immediate{
+					Register: 1,
+					Data:     ip,
+				},
+				&expr.Immediate{
+					Register: 2,
+					Data:     []byte{byte(to >> 8), byte(to & 0xff)},
+				},
+				&expr.NAT{
+					Type:        expr.NATTypeDestNAT,
+					Family:      unix.NFPROTO_IPV4,
+					RegAddrMin:  1,
+					RegProtoMin: 2,
+				},
+			},
+		})
+	}
+
+	// Apply the changes
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("failed to add dnat rule: %v", err)
+	}
+
+	return nil
+}
+
 // DeleteRules deletes any created rules by deleting the custom table created
 func DeleteRules() error {
+	return deleteTable(tableName)
+}
+
+// DeleteDNATRules deletes any rules created by AddOrUpdateDNAT
+func DeleteDNATRules() error {
+	return deleteTable(clientTableName)
+}
+
+// deleteTable deletes the table called name
+func deleteTable(name string) error {
 	conn := &nftables.Conn{}
 
 	tables, err := conn.ListTables()
@@ -51,73 +169,78 @@ func DeleteRules() error {
 
 	var tableToDelete *nftables.Table
 	for _, table := range tables {
-		if table.Name == tableName {
+		if table.Name == name {
 			tableToDelete = table
 			break
 		}
 	}
 
 	if tableToDelete == nil {
-		return fmt.Errorf("table %s not found", tableName)
+		return fmt.Errorf("table %s not found", name)
 	}
 
 	conn.DelTable(tableToDelete)
 
 	// Apply the changes
 	if err := conn.Flush(); err != nil {
-		return fmt.Errorf("failed to delete table %s: %v", tableName, err)
+		return fmt.Errorf("failed to delete table %s: %v", name, err)
 	}
 
 	return nil
 }
 
-// findAndUpdateRule finds an existing NAT rule by destination port and updates it with a new source port if needed
-func findAndUpdateRule(conn *nftables.Conn, table *nftables.Table, chain *nftables.Chain, newSrcPort, targetPort int) error {
+// findAndUpdateRule finds an existing NAT rule by jump name and updates it with a new source port if needed
+func findAndUpdateRule(conn *nftables.Conn, table *nftables.Table, chain *nftables.Chain, name, iface string, newSrcPort, targetPort int) error {
 	rules, err := conn.GetRules(table, chain)
 	if err != nil {
 		return fmt.Errorf("failed to get rules: %v", err)
 	}
 
 	for _, rule := range rules {
-		if ruleMatches(rule, targetPort) {
+		if ruleMatches(rule, name) {
 			if err := conn.DelRule(rule); err != nil {
 				return fmt.Errorf("failed to delete existing rule: %v", err)
 			}
 		}
 	}
 
-	return addRedirectRule(conn, table, chain, newSrcPort, targetPort)
+	return addRedirectRule(conn, table, chain, name, iface, newSrcPort, targetPort)
 }
 
-// ruleMatches checks if a rule matches the given source port
-func ruleMatches(rule *nftables.Rule, dstPort int) bool {
-	targetMatched := false
-	redirMatched := false
-
-	for _, e := range rule.Exprs {
-		switch expr := e.(type) {
-		case *expr.Redir:
-			redirMatched = true
-		case *expr.Immediate:
-			if len(expr.Data) == 2 {
-				ruleTargetPort := int(expr.Data[0])<<8 | int(expr.Data[1])
-				if ruleTargetPort == dstPort {
-					targetMatched = true
-				}
-			}
-		}
+// ruleMatches checks if a rule belongs to the jump called name
+func ruleMatches(rule *nftables.Rule, name string) bool {
+	return bytes.Equal(rule.UserData, []byte(name))
+}
 
-	}
+// ifname returns an interface name as the null padded value nftables compares with
+func ifname(name string) []byte {
+	b := make([]byte, unix.IFNAMSIZ)
+	copy(b, name+"\x00")
 
-	return targetMatched && redirMatched
+	return b
 }
 
 // addRedirectRule adds a new NAT redirect rule to the chain
-func addRedirectRule(conn *nftables.Conn, table *nftables.Table, chain *nftables.Chain, srcPort, targetPort int) error {
+func addRedirectRule(conn *nftables.Conn, table *nftables.Table, chain *nftables.Chain, name, iface string, srcPort, targetPort int) error {
+	var exprs []expr.Any
+
+	// Match the incoming interface
+	if iface != "" {
+		exprs = append(exprs,
+			&expr.Meta{Key: expr.MetaKeyIIFNAME, Register: 1},
+			&expr.Cmp{
+				Op:       expr.CmpOpEq,
+				Register: 1,
+				Data:     ifname(iface),
+			},
+		)
+	}
+
 	conn.AddRule(&nftables.Rule{
-		Table: table,
-		Chain: chain,
-		Exprs: []expr.Any{
+		Table:    table,
+		Chain:    chain,
+		UserData: []byte(name),
+		Exprs: append(exprs, []expr.Any{
 			// Match TCP packets
 			&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
 			&expr.Cmp{
@@ -140,12 +263,12 @@ func addRedirectRule(conn *nftables.Conn, table *nftables.Table, chain *nftables
 			// Redirect to targetPort (e.g., SSH on port 22)
 			&expr.Immediate{
 				Register: 1,
-				Data:     []byte{0, byte(targetPort)},
+				Data:     []byte{byte(targetPort >> 8), byte(targetPort & 0xff)},
 			},
 			&expr.Redir{
 				RegisterProtoMin: 1,
 			},
-		},
+		}...),
 	})
 
 	// Apply the changes
@@ -182,8 +305,8 @@ func getOrCreateTable(conn *nftables.Conn, tableName string, family nftables.Tab
 	return table, nil
 }
 
-// getOrCreateChain checks if a chain exists in the specified table, and creates it if it doesn't
-func getOrCreateChain(conn *nftables.Conn, table *nftables.Table, chainName string) (*nftables.Chain, error) {
+// getOrCreateChain checks if a chain exists in the specified table, and creates it on hook if it doesn't
+func getOrCreateChain(conn *nftables.Conn, table *nftables.Table, chainName string, hook *nftables.ChainHook) (*nftables.Chain, error) {
 	chains, err := conn.ListChains()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list chains: %v", err)
@@ -200,7 +323,7 @@ func getOrCreateChain(conn *nftables.Conn, table *nftables.Table, chainName stri
 		Name:     chainName,
 		Table:    table,
 		Type:     nftables.ChainTypeNAT,
-		Hooknum:  nftables.ChainHookPrerouting,
+		Hooknum:  hook,
 		Priority: nftables.ChainPriorityNATDest,
 	})
 
@@ -2,12 +2,15 @@
 
 package firewall
 
-import "errors"
+import (
+	"errors"
+	"net"
+)
 
 var errNotImplemented = errors.New("not implemented for non-linux systems")
 
 // AddOrUpdateRedirect updates the firewall using NFTables to redirect traffic from, to.
-func AddOrUpdateRedirect(from int, to int) error {
+func AddOrUpdateRedirect(name string, iface string, from int, to int) error {
 	return errNotImplemented
 }
 
@@ -15,3 +18,13 @@ func AddOrUpdateRedirect(from int, to int) error {
 func DeleteRules() error {
 	return errNotImplemented
 }
+
+// AddOrUpdateDNAT updates the firewall using NFTables to rewrite outgoing connections to from, to.
+func AddOrUpdateDNAT(name string, addrs []net.IP, from int, to int) error {
+	return errNotImplemented
+}
+
+// DeleteDNATRules deletes any rules created by AddOrUpdateDNAT
+func DeleteDNATRules() error {
+	return errNotImplemented
+}
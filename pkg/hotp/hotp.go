@@ -10,6 +10,15 @@ import (
 	"time"
 )
 
+const (
+	// Algorithm is the HMAC hash algorithm used to derive codes
+	Algorithm = "SHA1"
+	// MinPort is the lowest TCP port that can be generated
+	MinPort = 1024
+	// MaxPort is the highest TCP port that can be generated
+	MaxPort = 65535
+)
+
 // ref: https://www.ietf.org/rfc/rfc4226.txt
 type Hotp struct {
 	secret   string
@@ -32,15 +41,27 @@ func NewTotp(secret string, interval int64) (*Hotp, error) {
 	}, nil
 }
 
+// Window returns the start and end of the interval t falls in
+func (h *Hotp) Window(t time.Time) (time.Time, time.Time) {
+	start := time.Unix(t.Unix()/h.interval*h.interval, 0)
+
+	return start, start.Add(time.Duration(h.interval) * time.Second)
+}
+
 // Code returns an integer of a calculated HMAC
 func (h *Hotp) Code() (uint32, error) {
+	return h.CodeAt(time.Now())
+}
+
+// CodeAt returns an integer of a calculated HMAC for the interval t falls in
+func (h *Hotp) CodeAt(t time.Time) (uint32, error) {
 	secret := strings.ToUpper(h.secret)
 	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
 	if err != nil {
 		return 0, err
 	}
 
-	counter := time.Now().Unix() / h.interval
+	counter := t.Unix() / h.interval
 
 	var counterBytes [8]byte
 	binary.BigEndian.PutUint64(counterBytes[:], uint64(counter))
@@ -69,13 +90,18 @@ func (h *Hotp) Generate() (int, error) {
 
 // GenerateTCPPort generates a HOTP within the TCP high-port range.
 func (h *Hotp) GenerateTCPPort() (int, error) {
-	code, err := h.Code()
+	return h.GenerateTCPPortAt(time.Now())
+}
+
+// GenerateTCPPortAt generates a HOTP within the TCP high-port range for the interval t falls in.
+func (h *Hotp) GenerateTCPPortAt(t time.Time) (int, error) {
+	code, err := h.CodeAt(t)
 	if err != nil {
 		return 0, err
 	}
 
-	var minPort uint32 = 1024
-	var maxPort uint32 = 65535
+	var minPort uint32 = MinPort
+	var maxPort uint32 = MaxPort
 
 	return int(code%(maxPort-minPort+1) + minPort), nil
 }